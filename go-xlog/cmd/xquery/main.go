@@ -0,0 +1,123 @@
+// main.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+// A tool used to query the records of an xlog directory.
+//
+//	xquery -dir /tmp/log -start '2018-08-24 10:00:00' -level warn -tag db
+//	xquery -dir /tmp/log -f
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/X-Plan/xgo/go-xlog"
+	"github.com/X-Plan/xgo/go-xlog/xquery"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	flagDir        = flag.String("dir", "./log", "log directory")
+	flagStart      = flag.String("start", "", "only print the records after this time (format: '2006-01-02 15:04:05' or RFC3339)")
+	flagEnd        = flag.String("end", "", "only print the records before this time (format: same as 'start')")
+	flagLevel      = flag.String("level", "", "only print the records whose level is higher than or equal to it (fatal, error, warn, info, debug)")
+	flagTag        = flag.String("tag", "", "only print the records of these tags (separated by comma)")
+	flagTimeFormat = flag.String("time-format", "", "the layout of the timestamp in records")
+	flagFollow     = flag.Bool("f", false, "follow the active log file like 'tail -f'")
+	flagInterval   = flag.Duration("interval", time.Second, "the interval of checking new records when following")
+	flagList       = flag.Bool("list", false, "list the log files in chronological order instead of printing records")
+)
+
+func main() {
+	flag.Parse()
+
+	var (
+		err error
+		q   = &xquery.Query{
+			Dir:        *flagDir,
+			TimeFormat: *flagTimeFormat,
+			Follow:     *flagFollow,
+			Interval:   *flagInterval,
+		}
+	)
+
+	if q.Start, err = parseTime(*flagStart); err != nil {
+		exit("invalid start time (%s)", err)
+	}
+
+	if q.End, err = parseTime(*flagEnd); err != nil {
+		exit("invalid end time (%s)", err)
+	}
+
+	if *flagLevel != "" {
+		if q.Level = xquery.ParseLevel(*flagLevel); q.Level == 0 {
+			exit("invalid level (%s)", *flagLevel)
+		}
+	}
+
+	if *flagTag != "" {
+		q.Tags = strings.Split(*flagTag, ",")
+	}
+
+	if *flagList {
+		files, err := xlog.ListFiles(q.Dir, q.Start, q.End)
+		if err != nil {
+			exit("list files failed (%s)", err)
+		}
+		for _, f := range files {
+			fmt.Printf("%s\t%s\t%d\n", f.CreateTime.Format(time.RFC3339Nano), f.Path(), f.Size)
+		}
+		return
+	}
+
+	exitChan := make(chan int)
+	go func() {
+		sc := make(chan os.Signal, 1)
+		signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
+		<-sc
+		close(exitChan)
+	}()
+
+	w := bufio.NewWriter(os.Stdout)
+	err = q.Run(exitChan, func(e *xquery.Entry) error {
+		if _, err := w.WriteString(e.Line + "\n"); err != nil {
+			return err
+		}
+		// Print the new records immediately when following.
+		if q.Follow {
+			return w.Flush()
+		}
+		return nil
+	})
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+
+	if err != nil {
+		exit("query failed (%s)", err)
+	}
+}
+
+// Parse a time in the local time zone, the empty string represents the zero time.
+func parseTime(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(xquery.TextTimeFormat, str, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, str)
+}
+
+func exit(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
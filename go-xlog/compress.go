@@ -0,0 +1,133 @@
+// compress.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// The suffix of a compressed log file.
+const compressSuffix = ".gz"
+
+// Compress a closed log file by gzip. The data is written to a temporary
+// file which doesn't match the standard file name, so a partial compressed
+// file will never be counted by the cleanup work. The name of the temporary
+// file is unique, and the original file is locked while it's being compressed,
+// so the routines and the processes sharing the directory never compress the
+// same file simultaneously. If the original file is locked (it's active or
+// being compressed by another routine or process), errLocked is returned. The
+// name of the temporary file is returned, it should be committed by the
+// 'commitCompressed' function.
+func compressFile(dir string, t time.Time) (tmp string, err error) {
+	var (
+		in  *os.File
+		out *os.File
+		gzw *gzip.Writer
+	)
+
+	if in, err = os.Open(getName(dir, t)); err != nil {
+		return
+	}
+	// Closing the file releases the lock if it's acquired.
+	defer in.Close()
+
+	if err = flock(in, false); err != nil {
+		return
+	}
+
+	// The routines of a process are serialized by the lock of the original
+	// file, so the process ID makes the name of the temporary file unique.
+	tmp = getName(dir, t) + compressSuffix + "." + strconv.Itoa(os.Getpid()) + ".tmp"
+	if out, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666); err != nil {
+		return
+	}
+
+	gzw = gzip.NewWriter(out)
+	if _, err = io.Copy(gzw, in); err == nil {
+		err = gzw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return
+}
+
+// Replace the original log file with the compressed file. If the original
+// file has been removed by the cleanup work, the compressed file is also
+// discarded, otherwise the removed file would be resurrected.
+func commitCompressed(dir string, t time.Time, tmp string) (err error) {
+	src := getName(dir, t)
+	if _, err = os.Stat(src); err != nil {
+		os.Remove(tmp)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	if err = os.Rename(tmp, src+compressSuffix); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	return os.Remove(src)
+}
+
+// Compress a closed log file in the background. Only the latest error of
+// the background compression is kept, and it will be returned by the next
+// write operation.
+func (ds *dirSink) compressAsync(t time.Time) {
+	ds.wg.Add(1)
+	go func() {
+		defer ds.wg.Done()
+
+		tmp, err := compressFile(ds.dir, t)
+		if err == errLocked {
+			// It's being compressed by another routine or process.
+			err = nil
+		} else if err == nil {
+			// Committing and cleaning up can't be executed simultaneously.
+			if err = ds.lock(); err == nil {
+				err = commitCompressed(ds.dir, t, tmp)
+				ds.unlock()
+			} else {
+				os.Remove(tmp)
+			}
+		}
+
+		if err != nil {
+			select {
+			case ds.cerr <- err:
+			default:
+			}
+		}
+	}()
+}
+
+// Compress all uncompressed log files except the active ones, they may be
+// left by the previous process. The caller must hold the lock of the sink.
+func (ds *dirSink) compressBackups() error {
+	fiq, err := createFileInfoQueue(ds.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range *fiq {
+		if !fi.Compressed && (ds.f == nil || !fi.CreateTime.Equal(ds.f.CreateTime)) && !isActive(ds.dir, fi) {
+			ds.compressAsync(fi.CreateTime)
+		}
+	}
+	return nil
+}
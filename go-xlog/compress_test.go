@@ -0,0 +1,211 @@
+// compress_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"fmt"
+	"github.com/X-Plan/xgo/go-xassert"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressedName(t *testing.T) {
+	tm := time.Date(2018, 8, 24, 10, 20, 30, 100, time.Local)
+	name := time2name(tm)
+	xassert.IsTrue(t, isValidName(name))
+	xassert.IsTrue(t, isValidName(name+compressSuffix))
+	xassert.IsFalse(t, isValidName(name+compressSuffix+".tmp"))
+	xassert.IsFalse(t, isValidName(name+".zip"))
+	xassert.Equal(t, name2time(name+compressSuffix), tm)
+	xassert.Equal(t, fileInfo{tm, 0, true}.Name(), name+compressSuffix)
+}
+
+func TestCompress(t *testing.T) {
+	dir := "/tmp/xlog_compress"
+	defer os.RemoveAll(dir)
+
+	// A log file left by the previous process.
+	xassert.IsNil(t, os.MkdirAll(dir, 0777))
+	xassert.IsNil(t, ioutil.WriteFile(getName(dir, time.Now().Add(-time.Hour)), []byte("line -1\n"), 0666))
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, MaxSize: 64, MaxBackups: 5, Compress: true})
+	xassert.IsNil(t, err)
+	for i := 0; i < 10; i++ {
+		// Each record is larger than 64 bytes, so it has its own file.
+		xassert.IsNil(t, xl.Info("%s line %d", strings.Repeat("x", 64), i))
+	}
+	xassert.IsNil(t, xl.Close())
+
+	fiq, err := createFileInfoQueue(dir)
+	xassert.IsNil(t, err)
+	fiq.Sort()
+	xassert.Equal(t, fiq.Len(), 5)
+	for i, fi := range *fiq {
+		xassert.Equal(t, fi.Compressed, i < fiq.Len()-1)
+	}
+
+	r, err := NewFileReader(dir, time.Time{}, time.Time{})
+	xassert.IsNil(t, err)
+	data, err := ioutil.ReadAll(r)
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, r.Close())
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	xassert.Equal(t, len(lines), 5)
+	for i, line := range lines {
+		xassert.IsTrue(t, strings.HasSuffix(line, fmt.Sprintf("line %d", i+5)))
+	}
+}
+
+func TestReaderRange(t *testing.T) {
+	dir := "/tmp/xlog_reader"
+	defer os.RemoveAll(dir)
+	xassert.IsNil(t, os.MkdirAll(dir, 0777))
+
+	base := time.Date(2018, 8, 24, 10, 0, 0, 0, time.Local)
+	for i := 0; i < 5; i++ {
+		tm := base.Add(time.Duration(i) * time.Hour)
+		xassert.IsNil(t, ioutil.WriteFile(getName(dir, tm), []byte(fmt.Sprintf("%d\n", i)), 0666))
+		if i%2 == 0 {
+			tmp, err := compressFile(dir, tm)
+			xassert.IsNil(t, err)
+			xassert.IsNil(t, commitCompressed(dir, tm, tmp))
+		}
+	}
+
+	elements := []struct {
+		start, end time.Time
+		data       string
+	}{
+		{time.Time{}, time.Time{}, "0\n1\n2\n3\n4\n"},
+		{base.Add(90 * time.Minute), time.Time{}, "1\n2\n3\n4\n"},
+		{base.Add(2 * time.Hour), base.Add(3 * time.Hour), "2\n"},
+		{time.Time{}, base.Add(150 * time.Minute), "0\n1\n2\n"},
+		{base.Add(10 * time.Hour), time.Time{}, "4\n"},
+		{time.Time{}, base, ""},
+	}
+
+	for _, element := range elements {
+		r, err := NewFileReader(dir, element.start, element.end)
+		xassert.IsNil(t, err)
+		data, err := ioutil.ReadAll(r)
+		xassert.IsNil(t, err)
+		xassert.Equal(t, string(data), element.data)
+		xassert.IsNil(t, r.Close())
+	}
+}
+
+func TestListFilesNotExist(t *testing.T) {
+	dir := "/tmp/xlog_reader_not_exist"
+	os.RemoveAll(dir)
+
+	_, err := ListFiles(dir, time.Time{}, time.Time{})
+	xassert.IsTrue(t, os.IsNotExist(err))
+	_, err = NewFileReader(dir, time.Time{}, time.Time{})
+	xassert.IsTrue(t, os.IsNotExist(err))
+
+	// The directory isn't created by the read-only operations.
+	_, err = os.Stat(dir)
+	xassert.IsTrue(t, os.IsNotExist(err))
+}
+
+// The records are selected by their timestamps, including the ones in the
+// first and the last files.
+func TestFileReaderBoundary(t *testing.T) {
+	dir := "/tmp/xlog_reader_boundary"
+	base := time.Date(2018, 8, 24, 10, 0, 0, 0, time.Local)
+
+	for _, format := range []string{FormatText, FormatJSON, FormatLogfmt} {
+		os.RemoveAll(dir)
+		xassert.IsNil(t, os.MkdirAll(dir, 0777))
+
+		enc, err := NewEncoder(format, EncoderConfig{})
+		xassert.IsNil(t, err)
+		for i := 0; i < 3; i++ {
+			tm := base.Add(time.Duration(i) * time.Hour)
+			// Each file holds a record at the beginning and at the end of its hour.
+			var data []byte
+			for _, rt := range []time.Time{tm, tm.Add(50 * time.Minute)} {
+				data = append(data, enc.Encode(&Entry{Time: rt, Level: INFO, Tag: "test", Message: rt.Format("15:04")})...)
+			}
+			xassert.IsNil(t, ioutil.WriteFile(getName(dir, tm), data, 0666))
+		}
+
+		elements := []struct {
+			start, end time.Time
+			messages   []string
+		}{
+			{time.Time{}, time.Time{}, []string{"10:00", "10:50", "11:00", "11:50", "12:00", "12:50"}},
+			// 10:00 precedes 'start' and 11:50 follows 'end'.
+			{base.Add(30 * time.Minute), base.Add(90 * time.Minute), []string{"10:50", "11:00"}},
+			// The range begins and ends exactly at the records.
+			{base.Add(50 * time.Minute), base.Add(110 * time.Minute), []string{"10:50", "11:00"}},
+			// The range begins and ends exactly at the creation times of files.
+			{base.Add(time.Hour), base.Add(2 * time.Hour), []string{"11:00", "11:50"}},
+			// 12:00 precedes 'start' and 12:50 follows 'end' in the same file.
+			{base.Add(125 * time.Minute), base.Add(126 * time.Minute), nil},
+			{base.Add(170 * time.Minute), time.Time{}, []string{"12:50"}},
+			{time.Time{}, base.Add(10 * time.Minute), []string{"10:00"}},
+		}
+
+		for _, element := range elements {
+			r, err := NewFileReader(dir, element.start, element.end)
+			xassert.IsNil(t, err)
+			data, err := ioutil.ReadAll(r)
+			xassert.IsNil(t, err)
+			xassert.IsNil(t, r.Close())
+
+			var messages []string
+			for _, line := range strings.Split(string(data), "\n") {
+				for _, m := range []string{"10:00", "10:50", "11:00", "11:50", "12:00", "12:50"} {
+					if strings.Contains(line, m) {
+						messages = append(messages, m)
+					}
+				}
+			}
+			xassert.Equal(t, messages, element.messages)
+		}
+	}
+	os.RemoveAll(dir)
+}
+
+// A file exists in both formats when it's being compressed, it's listed once.
+func TestListFilesCompressing(t *testing.T) {
+	dir := "/tmp/xlog_reader_compressing"
+	defer os.RemoveAll(dir)
+	os.RemoveAll(dir)
+	xassert.IsNil(t, os.MkdirAll(dir, 0777))
+
+	base := time.Date(2018, 8, 24, 10, 0, 0, 0, time.Local)
+	for i := 0; i < 3; i++ {
+		tm := base.Add(time.Duration(i) * time.Hour)
+		xassert.IsNil(t, ioutil.WriteFile(getName(dir, tm), []byte(fmt.Sprintf("%d\n", i)), 0666))
+	}
+
+	// The compressed copy of the second file has been created, but the
+	// original one hasn't been removed.
+	tmp, err := compressFile(dir, base.Add(time.Hour))
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, os.Rename(tmp, getName(dir, base.Add(time.Hour))+compressSuffix))
+
+	files, err := ListFiles(dir, base.Add(90*time.Minute), time.Time{})
+	xassert.IsNil(t, err)
+	xassert.Equal(t, len(files), 2)
+	xassert.Equal(t, files[0].CreateTime, base.Add(time.Hour))
+	xassert.Equal(t, files[1].CreateTime, base.Add(2*time.Hour))
+
+	r, err := NewFileReader(dir, time.Time{}, time.Time{})
+	xassert.IsNil(t, err)
+	data, err := ioutil.ReadAll(r)
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, r.Close())
+	xassert.Equal(t, string(data), "0\n1\n2\n")
+}
@@ -0,0 +1,115 @@
+// context.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"context"
+)
+
+// The key of the request ID field, it's used by the helpers seeding a context
+// from an incoming request (see 'RequestID' function of xrouter package).
+const RequestIDKey = "request_id"
+
+// The key type of the fields stored in a context, it's unexported to avoid
+// collisions with the keys defined in other packages.
+type fieldsKey struct{}
+
+// NewContext returns a copy of the parent context which carries the given
+// fields, the fields of the parent context are preserved. The 'kvs' parameter
+// has the same format as the one of 'With' method. The context-aware methods
+// of XLogger (such as 'InfoContext') include these fields automatically.
+//
+//	ctx = xlog.NewContext(ctx, xlog.RequestIDKey, "9b2c0e1d")
+//	xl.InfoContext(ctx, "user %s login", "blinklv")
+//	// [2018-08-24 10:00:00][tag][INFO]:user blinklv login request_id=9b2c0e1d
+func NewContext(ctx context.Context, kvs ...interface{}) context.Context {
+	old := FieldsFromContext(ctx)
+	fields := toFields(kvs)
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	result := make([]Field, 0, len(old)+len(fields))
+	return context.WithValue(ctx, fieldsKey{}, append(append(result, old...), fields...))
+}
+
+// FieldsFromContext returns the fields carried by the context, the result
+// shouldn't be modified.
+func FieldsFromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
+
+// RequestIDFromContext returns the request ID carried by the context, it's
+// empty if the context doesn't carry one.
+func RequestIDFromContext(ctx context.Context) string {
+	fields := FieldsFromContext(ctx)
+	// The latest one takes precedence.
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == RequestIDKey {
+			if id, ok := fields[i].Value.(string); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// WithContext returns a child XLogger which attaches the fields carried by
+// the context to each record, it's useful to pass a logger to the functions
+// which don't accept a context.
+func (xl *XLogger) WithContext(ctx context.Context) *XLogger {
+	if xl == nil {
+		return nil
+	}
+
+	return &XLogger{
+		core:   xl.core,
+		tag:    xl.tag,
+		fields: xl.appendFields(FieldsFromContext(ctx)),
+		module: xl.module,
+	}
+}
+
+// FatalContext is same as 'Fatal' method, but includes the fields carried
+// by the context.
+func (xl *XLogger) FatalContext(ctx context.Context, format string, args ...interface{}) error {
+	_, err := xl.output(FATAL, xl.sprintf(format, args...), appendCauses(FieldsFromContext(ctx), args))
+	return err
+}
+
+// ErrorContext is same as 'Error' method, but includes the fields carried
+// by the context.
+func (xl *XLogger) ErrorContext(ctx context.Context, format string, args ...interface{}) error {
+	_, err := xl.output(ERROR, xl.sprintf(format, args...), appendCauses(FieldsFromContext(ctx), args))
+	return err
+}
+
+// WarnContext is same as 'Warn' method, but includes the fields carried
+// by the context.
+func (xl *XLogger) WarnContext(ctx context.Context, format string, args ...interface{}) error {
+	_, err := xl.output(WARN, xl.sprintf(format, args...), FieldsFromContext(ctx))
+	return err
+}
+
+// InfoContext is same as 'Info' method, but includes the fields carried
+// by the context.
+func (xl *XLogger) InfoContext(ctx context.Context, format string, args ...interface{}) error {
+	_, err := xl.output(INFO, xl.sprintf(format, args...), FieldsFromContext(ctx))
+	return err
+}
+
+// DebugContext is same as 'Debug' method, but includes the fields carried
+// by the context.
+func (xl *XLogger) DebugContext(ctx context.Context, format string, args ...interface{}) error {
+	_, err := xl.output(DEBUG, xl.sprintf(format, args...), FieldsFromContext(ctx))
+	return err
+}
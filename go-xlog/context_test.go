@@ -0,0 +1,58 @@
+// context_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"context"
+	"github.com/X-Plan/xgo/go-xassert"
+	"os"
+	"testing"
+)
+
+func TestNewContext(t *testing.T) {
+	ctx := context.Background()
+	xassert.IsNil(t, FieldsFromContext(ctx))
+	xassert.Equal(t, RequestIDFromContext(ctx), "")
+	xassert.Equal(t, NewContext(ctx), ctx)
+
+	parent := NewContext(ctx, RequestIDKey, "1", "user", "blinklv")
+	child := NewContext(parent, RequestIDKey, "2")
+	xassert.Equal(t, FieldsFromContext(parent), []Field{{RequestIDKey, "1"}, {"user", "blinklv"}})
+	xassert.Equal(t, FieldsFromContext(child), []Field{{RequestIDKey, "1"}, {"user", "blinklv"}, {RequestIDKey, "2"}})
+	xassert.Equal(t, RequestIDFromContext(parent), "1")
+	xassert.Equal(t, RequestIDFromContext(child), "2")
+}
+
+func TestContextMethods(t *testing.T) {
+	dir := "/tmp/xlog_context"
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{Dir: dir, Level: DEBUG, Location: "never"})
+	xassert.IsNil(t, err)
+
+	ctx := NewContext(context.Background(), RequestIDKey, "9b2c0e1d")
+	child := xl.With("user", "blinklv")
+	xassert.IsNil(t, child.ErrorContext(ctx, "error %d", 1))
+	xassert.IsNil(t, child.WarnContext(ctx, "warn %d", 2))
+	xassert.IsNil(t, child.InfoContext(ctx, "info %d", 3))
+	xassert.IsNil(t, child.DebugContext(context.Background(), "debug %d", 4))
+	xassert.IsNil(t, xl.WithContext(ctx).Info("with context"))
+	xassert.IsNil(t, xl.Close())
+
+	checkLines(t, readLines(t, dir),
+		"[ERROR]:error 1 user=blinklv request_id=9b2c0e1d",
+		"[WARN]:warn 2 user=blinklv request_id=9b2c0e1d",
+		"[INFO]:info 3 user=blinklv request_id=9b2c0e1d",
+		"[DEBUG]:debug 4 user=blinklv",
+		"[INFO]:with context request_id=9b2c0e1d",
+	)
+
+	var nilxl *XLogger
+	xassert.IsNil(t, nilxl.WithContext(ctx))
+	xassert.IsNil(t, nilxl.InfoContext(ctx, "hello"))
+}
@@ -0,0 +1,255 @@
+// encoder.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a log record which will be encoded to a line by an Encoder.
+type Entry struct {
+	Time    time.Time
+	Level   int
+	Tag     string
+	Caller  *Caller // It's nil when the location is excluded.
+	Message string
+	Fields  []Field
+}
+
+// Caller is the location of an event happening. If getting location is
+// failed, all fields are empty.
+type Caller struct {
+	File     string
+	Line     int
+	Function string
+}
+
+// The string format of a Caller is 'file(line) - function'.
+func (c *Caller) String() string {
+	if c.File == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s(%d) - %s", c.File, c.Line, c.Function)
+}
+
+// Encoder converts an Entry to a line. The result must end with '\n', and it
+// can't be modified by the Encoder after returning, because it will be written
+// asynchronously. If an Encoder has the 'NeedLocation(level int) bool' method
+// (the builtin encoders get it from EncoderConfig), the location of an Entry
+// is only got when the method returns true, otherwise it's always got.
+type Encoder interface {
+	Encode(e *Entry) []byte
+}
+
+// The optional interface of Encoder, see Encoder for details.
+type locationNeeder interface {
+	NeedLocation(level int) bool
+}
+
+// EncoderConfig controls the common behaviours of the builtin encoders.
+type EncoderConfig struct {
+	// The layout (see time.Format) of the timestamp. If it's empty, the
+	// text encoder uses '2006-01-02 15:04:05', the json and logfmt encoders
+	// use time.RFC3339.
+	TimeFormat string
+
+	// Whether including the location of an event happening, the valid values
+	// are LocationAuto, LocationAlways and LocationNever. If it's empty,
+	// LocationAuto will be used by default.
+	Location string
+}
+
+// The policies of including the location of an event happening.
+const (
+	LocationAuto   = "auto"   // Include the location except INFO level.
+	LocationAlways = "always" // Always include the location.
+	LocationNever  = "never"  // Never include the location.
+)
+
+func checkLocation(str string) error {
+	switch str {
+	case "", LocationAuto, LocationAlways, LocationNever:
+		return nil
+	}
+	return fmt.Errorf("unknown location (%s)", str)
+}
+
+// NeedLocation reports whether the location of a record with the given level
+// is included.
+func (cfg EncoderConfig) NeedLocation(level int) bool {
+	switch cfg.Location {
+	case LocationAlways:
+		return true
+	case LocationNever:
+		return false
+	}
+	return level != INFO
+}
+
+// The default layout of the timestamp of the text encoder.
+const textTimeFormat = "2006-01-02 15:04:05"
+
+// The formats of the builtin encoders.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// NewEncoder returns a builtin encoder by the format name. An empty format
+// represents the text format.
+func NewEncoder(format string, cfg EncoderConfig) (Encoder, error) {
+	if err := checkLocation(cfg.Location); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "", FormatText:
+		if cfg.TimeFormat == "" {
+			cfg.TimeFormat = textTimeFormat
+		}
+		return &TextEncoder{cfg}, nil
+	case FormatJSON:
+		if cfg.TimeFormat == "" {
+			cfg.TimeFormat = time.RFC3339
+		}
+		return &JSONEncoder{cfg}, nil
+	case FormatLogfmt:
+		if cfg.TimeFormat == "" {
+			cfg.TimeFormat = time.RFC3339
+		}
+		return &LogfmtEncoder{cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown format (%s)", format)
+}
+
+// TextEncoder is the default encoder, its format is:
+// [time][tag][level][location]:message key=value key=value
+type TextEncoder struct {
+	EncoderConfig
+}
+
+func (te *TextEncoder) Encode(e *Entry) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("[")
+	buf.WriteString(e.Time.Format(te.TimeFormat))
+	buf.WriteString("][")
+	buf.WriteString(e.Tag)
+	buf.WriteString("]")
+	buf.WriteString(levelTags[e.Level])
+	if e.Caller != nil {
+		buf.WriteString("[")
+		buf.WriteString(e.Caller.String())
+		buf.WriteString("]")
+	}
+	buf.WriteString(":")
+	buf.WriteString(e.Message)
+	buf.WriteString(fieldsTag(e.Fields))
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// JSONEncoder encodes an Entry to a JSON object per line, the fields of the
+// Entry are placed at the top level of the object:
+// {"time":"...","tag":"...","level":"INFO","caller":"...","msg":"...","key":"value"}
+type JSONEncoder struct {
+	EncoderConfig
+}
+
+func (je *JSONEncoder) Encode(e *Entry) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"time":`)
+	buf.WriteString(strconv.Quote(e.Time.Format(je.TimeFormat)))
+	buf.WriteString(`,"tag":`)
+	writeJSON(buf, e.Tag)
+	buf.WriteString(`,"level":`)
+	buf.WriteString(strconv.Quote(strings.ToUpper(levelReadable[e.Level])))
+	if e.Caller != nil && e.Caller.File != "" {
+		buf.WriteString(`,"caller":`)
+		writeJSON(buf, e.Caller.File+":"+strconv.Itoa(e.Caller.Line))
+		buf.WriteString(`,"func":`)
+		writeJSON(buf, e.Caller.Function)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, e.Message)
+	for _, f := range e.Fields {
+		buf.WriteString(",")
+		writeJSON(buf, f.Key)
+		buf.WriteString(":")
+		writeJSON(buf, f.Value)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// Write the JSON encoding of a value to the buffer. An error is encoded to its
+// message, and a value can't be encoded is converted to its string format.
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+// LogfmtEncoder encodes an Entry to the logfmt format:
+// time=... level=info tag=... caller=file:line msg=... key=value
+type LogfmtEncoder struct {
+	EncoderConfig
+}
+
+func (le *LogfmtEncoder) Encode(e *Entry) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("time=")
+	buf.WriteString(quote(e.Time.Format(le.TimeFormat)))
+	buf.WriteString(" level=")
+	buf.WriteString(levelReadable[e.Level])
+	buf.WriteString(" tag=")
+	buf.WriteString(quote(e.Tag))
+	if e.Caller != nil && e.Caller.File != "" {
+		buf.WriteString(" caller=")
+		buf.WriteString(quote(e.Caller.File + ":" + strconv.Itoa(e.Caller.Line)))
+	}
+	buf.WriteString(" msg=")
+	buf.WriteString(quote(e.Message))
+	buf.WriteString(fieldsTag(e.Fields))
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// Get the program counter of the caller, the 'skip' parameter is same as the
+// one of the runtime.Caller function but relative to the caller of this function.
+// Zero represents the location failure.
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	// The first two frames are runtime.Callers and this function.
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return 0
+	}
+	return pcs[0]
+}
+
+// Convert a program counter returned by 'callerPC' function to a Caller.
+func pc2caller(pc uintptr) *Caller {
+	if pc == 0 {
+		return &Caller{} // Location failure.
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return &Caller{File: filepath.Base(frame.File), Line: frame.Line, Function: frame.Function}
+}
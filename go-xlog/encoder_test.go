@@ -0,0 +1,100 @@
+// encoder_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"errors"
+	"github.com/X-Plan/xgo/go-xassert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEncoders(t *testing.T) {
+	e := &Entry{
+		Time:    time.Date(2018, 8, 24, 10, 20, 30, 0, time.UTC),
+		Level:   ERROR,
+		Tag:     "test",
+		Caller:  &Caller{File: "main.go", Line: 12, Function: "main.main"},
+		Message: "hello world",
+		Fields:  []Field{{"id", 10}, {"err", errors.New("bad request")}},
+	}
+
+	elements := []struct {
+		format string
+		line   string
+	}{
+		{"", "[2018-08-24 10:20:30][test][ERROR][main.go(12) - main.main]:hello world id=10 err=\"bad request\"\n"},
+		{FormatJSON, `{"time":"2018-08-24T10:20:30Z","tag":"test","level":"ERROR","caller":"main.go:12","func":"main.main","msg":"hello world","id":10,"err":"bad request"}` + "\n"},
+		{FormatLogfmt, `time=2018-08-24T10:20:30Z level=error tag=test caller=main.go:12 msg="hello world" id=10 err="bad request"` + "\n"},
+	}
+
+	for _, element := range elements {
+		enc, err := NewEncoder(element.format, EncoderConfig{})
+		xassert.IsNil(t, err)
+		xassert.Equal(t, string(enc.Encode(e)), element.line)
+	}
+
+	enc, err := NewEncoder(FormatText, EncoderConfig{TimeFormat: "15:04"})
+	xassert.IsNil(t, err)
+	e.Caller, e.Fields = nil, nil
+	xassert.Equal(t, string(enc.Encode(e)), "[10:20][test][ERROR]:hello world\n")
+
+	enc, err = NewEncoder("xml", EncoderConfig{})
+	xassert.IsNil(t, enc)
+	xassert.NotNil(t, err)
+}
+
+func TestNeedLocation(t *testing.T) {
+	xassert.IsTrue(t, EncoderConfig{}.NeedLocation(ERROR))
+	xassert.IsFalse(t, EncoderConfig{Location: LocationAuto}.NeedLocation(INFO))
+	xassert.IsTrue(t, EncoderConfig{Location: LocationAlways}.NeedLocation(INFO))
+	xassert.IsFalse(t, EncoderConfig{Location: LocationNever}.NeedLocation(FATAL))
+
+	enc, err := NewEncoder(FormatJSON, EncoderConfig{Location: "sometimes"})
+	xassert.IsNil(t, enc)
+	xassert.NotNil(t, err)
+
+	// The builtin encoders decide whether including the location, and a
+	// customized encoder without 'NeedLocation' method always gets it.
+	elements := []struct {
+		encoder Encoder
+		caller  bool
+	}{
+		{&TextEncoder{EncoderConfig{Location: LocationNever}}, false},
+		{&TextEncoder{EncoderConfig{}}, false},
+		{&JSONEncoder{EncoderConfig{Location: LocationAlways}}, true},
+		{captureEncoder{}, true},
+	}
+	for _, element := range elements {
+		c := &core{encoder: element.encoder}
+		xassert.Equal(t, c.needLocation(INFO), element.caller)
+	}
+}
+
+type captureEncoder struct{}
+
+func (captureEncoder) Encode(e *Entry) []byte {
+	return []byte(e.Message + "\n")
+}
+
+func TestJSONFormat(t *testing.T) {
+	dir := "/tmp/xlog_json"
+	xl, err := New(&XConfig{Dir: dir, Tag: "json", Level: DEBUG, Format: FormatJSON, Location: "never"})
+	xassert.IsNil(t, err)
+	defer os.RemoveAll(dir)
+
+	xassert.IsNil(t, xl.Error("printf %d", 1))
+	xassert.IsNil(t, xl.With("a", "b").Infow("structured", "n", 2))
+	xassert.IsNil(t, xl.Close())
+
+	lines := readLines(t, dir)
+	xassert.Equal(t, len(lines), 2)
+	xassert.Match(t, lines[0], `^\{"time":"[^"]+","tag":"json","level":"ERROR","msg":"printf 1"\}$`)
+	xassert.Match(t, lines[1], `^\{"time":"[^"]+","tag":"json","level":"INFO","msg":"structured","a":"b","n":2\}$`)
+}
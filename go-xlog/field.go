@@ -0,0 +1,154 @@
+// field.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Field is a key-value pair attached to a log record. Fields are carried
+// through to the output line as 'key=value', so the log pipeline doesn't
+// need to parse the free text message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// The string format of a Field is 'key=value'. If the key or the value
+// contains spaces, quotes, '=' or unprintable characters, it will be quoted.
+func (f Field) String() string {
+	return quote(f.Key) + "=" + quote(fmt.Sprint(f.Value))
+}
+
+// This key is used when a key-value list is malformed, for example, a key
+// isn't a string or the last key hasn't a value.
+const badKey = "!BADKEY"
+
+// With returns a child XLogger which attaches the given fields to each record.
+// The 'kvs' parameter is an alternating key-value list, and a Field instance
+// can be mixed in it. The child shares the same directory and buffer channel
+// with its parent, so printf-style and structured records can be written into
+// the same file.
+//
+//	child := xl.With("user", "blinklv", "id", 10)
+//	child.Infow("login", "ip", "127.0.0.1")
+//	// [2018-08-24 10:00:00][tag][INFO]:login user=blinklv id=10 ip=127.0.0.1
+func (xl *XLogger) With(kvs ...interface{}) *XLogger {
+	if xl == nil {
+		return nil
+	}
+
+	return &XLogger{
+		core:   xl.core,
+		tag:    xl.tag,
+		fields: xl.appendFields(toFields(kvs)),
+		module: xl.module,
+	}
+}
+
+// Fatalw writes a FATAL record with a message and some key-value pairs.
+func (xl *XLogger) Fatalw(msg string, kvs ...interface{}) error {
+	_, err := xl.output(FATAL, msg, toFields(kvs))
+	return err
+}
+
+// Errorw writes an ERROR record with a message and some key-value pairs.
+func (xl *XLogger) Errorw(msg string, kvs ...interface{}) error {
+	_, err := xl.output(ERROR, msg, toFields(kvs))
+	return err
+}
+
+// Warnw writes a WARN record with a message and some key-value pairs.
+func (xl *XLogger) Warnw(msg string, kvs ...interface{}) error {
+	_, err := xl.output(WARN, msg, toFields(kvs))
+	return err
+}
+
+// Infow writes an INFO record with a message and some key-value pairs.
+func (xl *XLogger) Infow(msg string, kvs ...interface{}) error {
+	_, err := xl.output(INFO, msg, toFields(kvs))
+	return err
+}
+
+// Debugw writes a DEBUG record with a message and some key-value pairs.
+func (xl *XLogger) Debugw(msg string, kvs ...interface{}) error {
+	_, err := xl.output(DEBUG, msg, toFields(kvs))
+	return err
+}
+
+// Append fields to the fields of the XLogger. The result never shares the
+// underlying array with the XLogger, so sibling children won't overwrite
+// each other.
+func (xl *XLogger) appendFields(fields []Field) []Field {
+	if len(fields) == 0 {
+		return xl.fields
+	}
+	result := make([]Field, 0, len(xl.fields)+len(fields))
+	return append(append(result, xl.fields...), fields...)
+}
+
+// Convert an alternating key-value list to fields.
+func toFields(kvs []interface{}) []Field {
+	if len(kvs) == 0 {
+		return nil
+	}
+
+	fields := make([]Field, 0, (len(kvs)+1)/2)
+	for i := 0; i < len(kvs); i++ {
+		switch k := kvs[i].(type) {
+		case Field:
+			fields = append(fields, k)
+		case string:
+			if i+1 < len(kvs) {
+				fields = append(fields, Field{k, kvs[i+1]})
+				i++
+			} else {
+				fields = append(fields, Field{badKey, k})
+			}
+		default:
+			fields = append(fields, Field{badKey, k})
+		}
+	}
+	return fields
+}
+
+// Fields tag, each field is prefixed with a space.
+func fieldsTag(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	strs := make([]string, 0, len(fields)+1)
+	strs = append(strs, "")
+	for _, f := range fields {
+		strs = append(strs, f.String())
+	}
+	return strings.Join(strs, " ")
+}
+
+func quote(s string) string {
+	if needQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needQuote(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
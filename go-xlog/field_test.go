@@ -0,0 +1,101 @@
+// field_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"errors"
+	"github.com/X-Plan/xgo/go-xassert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestToFields(t *testing.T) {
+	elements := []struct {
+		kvs    []interface{}
+		fields []Field
+	}{
+		{nil, nil},
+		{[]interface{}{"a", 1}, []Field{{"a", 1}}},
+		{[]interface{}{"a", 1, "b"}, []Field{{"a", 1}, {badKey, "b"}}},
+		{[]interface{}{Field{"c", true}, "a", "x"}, []Field{{"c", true}, {"a", "x"}}},
+		{[]interface{}{10, "a", 1}, []Field{{badKey, 10}, {"a", 1}}},
+	}
+
+	for _, element := range elements {
+		fields := toFields(element.kvs)
+		xassert.Equal(t, len(fields), len(element.fields))
+		for i := range fields {
+			xassert.Equal(t, fields[i], element.fields[i])
+		}
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	elements := []struct {
+		f   Field
+		str string
+	}{
+		{Field{"a", 1}, "a=1"},
+		{Field{"name", "hello world"}, `name="hello world"`},
+		{Field{"empty", ""}, `empty=""`},
+		{Field{"err", errors.New("a=b")}, `err="a=b"`},
+		{Field{"k v", "x\n"}, `"k v"="x\n"`},
+	}
+
+	for _, element := range elements {
+		xassert.Equal(t, element.f.String(), element.str)
+	}
+}
+
+func TestWith(t *testing.T) {
+	var nilxl *XLogger
+	xassert.IsNil(t, nilxl.With("a", 1))
+	xassert.IsNil(t, nilxl.Infow("nothing", "a", 1))
+
+	dir := "/tmp/xlog_with"
+	xl, err := New(&XConfig{Dir: dir, Tag: "with", Level: DEBUG})
+	xassert.IsNil(t, err)
+	defer os.RemoveAll(dir)
+
+	parent := xl.With("a", 1)
+	child1 := parent.With("b", 2)
+	child2 := parent.With("c", 3)
+	xassert.Equal(t, len(xl.fields), 0)
+	xassert.Equal(t, len(parent.fields), 1)
+	xassert.Equal(t, child1.fields[1], Field{"b", 2})
+	xassert.Equal(t, child2.fields[1], Field{"c", 3})
+
+	xassert.IsNil(t, xl.Info("printf %d", 0))
+	xassert.IsNil(t, child1.Infow("structured", "d", "x y"))
+	xassert.IsNil(t, child2.Errorw("failed"))
+	xassert.IsNil(t, child2.Close())
+
+	lines := readLines(t, dir)
+	xassert.Equal(t, len(lines), 3)
+	xassert.Match(t, lines[0], `\[with\]\[INFO\]:printf 0$`)
+	xassert.Match(t, lines[1], `\[with\]\[INFO\]:structured a=1 b=2 d="x y"$`)
+	xassert.Match(t, lines[2], `\[with\]\[ERROR\]\[field_test.go\(\d+\) - .+\]:failed a=1 c=3$`)
+}
+
+// Read all lines of the log files in a directory.
+func readLines(t *testing.T, dir string) []string {
+	fiq, err := createFileInfoQueue(dir)
+	xassert.IsNil(t, err)
+	fiq.Sort()
+
+	var lines []string
+	for _, fi := range *fiq {
+		data, err := ioutil.ReadFile(filepath.Join(dir, time2name(fi.CreateTime)))
+		xassert.IsNil(t, err)
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")...)
+	}
+	return lines
+}
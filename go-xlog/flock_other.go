@@ -0,0 +1,25 @@
+// flock_other.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package xlog
+
+import (
+	"os"
+)
+
+// The advisory lock isn't supported on this platform, so the processes
+// sharing a directory aren't coordinated.
+func flock(fp *os.File, block bool) error {
+	return nil
+}
+
+func funlock(fp *os.File) error {
+	return nil
+}
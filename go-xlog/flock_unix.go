@@ -0,0 +1,45 @@
+// flock_unix.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package xlog
+
+import (
+	"os"
+	"syscall"
+)
+
+// Acquire the exclusive advisory lock of a file. If 'block' parameter is
+// false and the lock is held by another open file (maybe in another process),
+// errLocked will be returned immediately. The lock is released when the file
+// is closed.
+func flock(fp *os.File, block bool) error {
+	how := syscall.LOCK_EX
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	for {
+		err := syscall.Flock(int(fp.Fd()), how)
+		switch err {
+		case nil:
+			return nil
+		case syscall.EINTR:
+			continue
+		case syscall.EWOULDBLOCK:
+			return errLocked
+		}
+		return err
+	}
+}
+
+// Release the advisory lock of a file.
+func funlock(fp *os.File) error {
+	return syscall.Flock(int(fp.Fd()), syscall.LOCK_UN)
+}
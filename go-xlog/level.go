@@ -0,0 +1,229 @@
+// level.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// Module returns a child XLogger belonging to the named module, its tag is
+// built in the same way as the one of 'Named' method, for example, the tag of
+// xl.Named("svc").Module("db") is 'svc.db'. The child shares the same sinks
+// and buffer channel with its parent, but its level can be overridden by
+// 'SetLevel' method without affecting other modules. If the level of a module
+// isn't overridden, it follows the level of the root module. The level of a
+// module is keyed by its name instead of its tag.
+func (xl *XLogger) Module(name string) *XLogger {
+	if xl == nil {
+		return nil
+	}
+
+	xl.setModuleLevel(name, 0, false)
+	return &XLogger{
+		core:   xl.core,
+		tag:    xl.childTag(name),
+		fields: xl.fields,
+		module: name,
+	}
+}
+
+// Named returns a child XLogger whose tag is the tag of its parent appended
+// with the name, the two parts are separated by '.'. The child shares the same
+// sinks and buffer channel with its parent, so each subsystem of a service can
+// get its own identifiable tag without another directory. The 'kvs' parameter
+// has the same format as the one of 'With' method, these fields are attached
+// to each record of the child. The child belongs to the same module as its
+// parent, so they share the same level.
+//
+//	conn := xl.Module("db").Named("conn", "pool", 1)
+//	conn.Info("connected")
+//	// [2018-08-24 10:00:00][svc.db.conn][INFO]:connected pool=1
+func (xl *XLogger) Named(name string, kvs ...interface{}) *XLogger {
+	if xl == nil {
+		return nil
+	}
+
+	return &XLogger{
+		core:   xl.core,
+		tag:    xl.childTag(name),
+		fields: xl.appendFields(toFields(kvs)),
+		module: xl.module,
+	}
+}
+
+// Get the tag of a child XLogger, it's the tag of the XLogger appended with
+// the name, the two parts are separated by '.'.
+func (xl *XLogger) childTag(name string) string {
+	if xl.tag != "" && name != "" {
+		return xl.tag + "." + name
+	} else if name == "" {
+		return xl.tag
+	}
+	return name
+}
+
+// Level returns the current level of the module which the XLogger belongs to.
+func (xl *XLogger) Level() int {
+	if xl == nil {
+		return 0
+	}
+	return xl.getLevel(xl.module)
+}
+
+// SetLevel changes the level of the module which the XLogger belongs to
+// atomically. For the root module, the level of all modules which haven't
+// been overridden are also changed.
+func (xl *XLogger) SetLevel(level int) error {
+	if xl == nil {
+		return nil
+	}
+
+	if level < FATAL || level > DEBUG {
+		return fmt.Errorf("level (%d) is invalid", level)
+	}
+
+	if xl.module == "" {
+		atomic.StoreInt32(&xl.core.level, int32(level))
+	} else {
+		xl.setModuleLevel(xl.module, level, true)
+	}
+	return nil
+}
+
+// ResetLevel removes the overridden level of the module which the XLogger
+// belongs to, so it will follow the level of the root module again. It
+// does nothing for the root module.
+func (xl *XLogger) ResetLevel() {
+	if xl != nil && xl.module != "" {
+		xl.setModuleLevel(xl.module, 0, true)
+	}
+}
+
+func (c *core) getLevel(module string) int {
+	if module != "" {
+		if level := c.modules.Load().(map[string]int)[module]; level != 0 {
+			return level
+		}
+	}
+	return int(atomic.LoadInt32(&c.level))
+}
+
+// Set the overridden level of a module, zero level represents the module
+// follows the level of the root module. If 'overwrite' parameter is false,
+// an existing module won't be changed.
+func (c *core) setModuleLevel(module string, level int, overwrite bool) {
+	c.mmtx.Lock()
+	defer c.mmtx.Unlock()
+
+	old := c.modules.Load().(map[string]int)
+	if _, ok := old[module]; ok && !overwrite {
+		return
+	}
+
+	modules := make(map[string]int, len(old)+1)
+	for name, l := range old {
+		modules[name] = l
+	}
+	modules[module] = level
+	c.modules.Store(modules)
+}
+
+// The readable format of levels, it's used by the level handler. The level
+// of a module is empty if it isn't overridden.
+type readableLevels struct {
+	Level   string            `json:"level,omitempty"`
+	Modules map[string]string `json:"modules,omitempty"`
+}
+
+// LevelHandler returns an http.Handler which reads and changes the levels
+// at runtime. A GET request returns the levels as follows:
+//
+//	{"level":"info","modules":{"db":"debug","http":""}}
+//
+// A PUT or POST request changes the levels, its body has the same format as
+// the response of a GET request, and all fields are optional. An empty level
+// of a module removes the overridden level. The handler can be mounted on an
+// admin endpoint of xrouter:
+//
+//	h := xl.LevelHandler()
+//	xr.Handle("GET", "/admin/log", func(w http.ResponseWriter, r *http.Request, _ xrouter.XParams) {
+//		h.ServeHTTP(w, r)
+//	})
+func (xl *XLogger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+		case "PUT", "POST":
+			rls := &readableLevels{}
+			if err := json.NewDecoder(r.Body).Decode(rls); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := xl.setReadableLevels(rls); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(405), 405)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(xl.readableLevels())
+	})
+}
+
+func (xl *XLogger) readableLevels() *readableLevels {
+	rls := &readableLevels{
+		Level:   levelReadable[xl.getLevel("")],
+		Modules: make(map[string]string),
+	}
+	for name, level := range xl.modules.Load().(map[string]int) {
+		rls.Modules[name] = levelReadable[level]
+	}
+	return rls
+}
+
+// All levels are checked before changing any of them.
+func (xl *XLogger) setReadableLevels(rls *readableLevels) (err error) {
+	var (
+		level   int
+		modules = make(map[string]int, len(rls.Modules))
+	)
+
+	if rls.Level != "" {
+		if level, err = parseLevel(rls.Level); err != nil {
+			return
+		}
+	}
+
+	for name, str := range rls.Modules {
+		if name == "" {
+			return fmt.Errorf("module name can't be empty")
+		}
+		if str != "" {
+			if modules[name], err = parseLevel(str); err != nil {
+				return
+			}
+		} else {
+			modules[name] = 0
+		}
+	}
+
+	if level != 0 {
+		atomic.StoreInt32(&xl.core.level, int32(level))
+	}
+	for name, l := range modules {
+		xl.setModuleLevel(name, l, true)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// level_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"encoding/json"
+	"github.com/X-Plan/xgo/go-xassert"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetLevel(t *testing.T) {
+	dir := "/tmp/xlog_level"
+	xl, err := New(&XConfig{Dir: dir, Tag: "level", Level: INFO})
+	xassert.IsNil(t, err)
+	defer os.RemoveAll(dir)
+
+	db, rpc := xl.Module("db"), xl.Module("rpc")
+	xassert.Equal(t, db.tag, "level.db")
+	xassert.Equal(t, db.Level(), INFO)
+	xassert.NotNil(t, xl.SetLevel(0))
+	xassert.NotNil(t, xl.SetLevel(DEBUG+1))
+
+	xassert.IsNil(t, xl.Debug("root debug 1"))
+	xassert.IsNil(t, db.SetLevel(DEBUG))
+	xassert.IsNil(t, db.Debug("db debug 1"))
+	xassert.IsNil(t, rpc.Debug("rpc debug 1"))
+
+	xassert.IsNil(t, xl.SetLevel(DEBUG))
+	xassert.Equal(t, rpc.Level(), DEBUG)
+	xassert.IsNil(t, xl.Debug("root debug 2"))
+	xassert.IsNil(t, rpc.Debug("rpc debug 2"))
+
+	xassert.IsNil(t, xl.SetLevel(WARN))
+	xassert.Equal(t, db.Level(), DEBUG)
+	db.ResetLevel()
+	xassert.Equal(t, db.Level(), WARN)
+	xassert.IsNil(t, db.With("a", 1).Debug("db debug 2"))
+	xassert.IsNil(t, xl.Close())
+
+	checkLines(t, readLines(t, dir), "db debug 1", "root debug 2", "rpc debug 2")
+}
+
+func TestNamed(t *testing.T) {
+	dir := "/tmp/xlog_named"
+	xl, err := New(&XConfig{Dir: dir, Tag: "svc", Level: INFO, Location: "never"})
+	xassert.IsNil(t, err)
+	defer os.RemoveAll(dir)
+
+	db := xl.Module("db")
+	conn := db.Named("conn", "pool", 1)
+	xassert.Equal(t, conn.tag, "svc.db.conn")
+	xassert.Equal(t, xl.Named("http").Named("server").tag, "svc.http.server")
+	xassert.Equal(t, xl.Named("").tag, "svc")
+
+	// A module keeps the tag of its parent, but its level is keyed by its name.
+	pool := xl.Named("http").Module("pool")
+	xassert.Equal(t, pool.tag, "svc.http.pool")
+	xassert.IsNil(t, pool.SetLevel(DEBUG))
+	xassert.Equal(t, xl.Module("pool").Level(), DEBUG)
+	xassert.IsNil(t, pool.Debug("pool debug"))
+	pool.ResetLevel()
+
+	// The child follows the level of its module.
+	xassert.IsNil(t, conn.Debug("ignored"))
+	xassert.IsNil(t, db.SetLevel(DEBUG))
+	xassert.IsNil(t, conn.Debug("connected"))
+	xassert.IsNil(t, conn.With("id", 2).Info("closed"))
+	xassert.IsNil(t, xl.Named("http").Info("listen"))
+	xassert.IsNil(t, xl.Close())
+
+	lines := readLines(t, dir)
+	xassert.Equal(t, len(lines), 4)
+	xassert.IsTrue(t, strings.HasSuffix(lines[0], "[svc.http.pool][DEBUG]:pool debug"))
+	xassert.IsTrue(t, strings.HasSuffix(lines[1], "[svc.db.conn][DEBUG]:connected pool=1"))
+	xassert.IsTrue(t, strings.HasSuffix(lines[2], "[svc.db.conn][INFO]:closed pool=1 id=2"))
+	xassert.IsTrue(t, strings.HasSuffix(lines[3], "[svc.http][INFO]:listen"))
+
+	var nilxl *XLogger
+	xassert.IsNil(t, nilxl.Named("nil"))
+}
+
+func TestLevelHandler(t *testing.T) {
+	dir := "/tmp/xlog_level_handler"
+	xl, err := New(&XConfig{Dir: dir, Level: INFO})
+	xassert.IsNil(t, err)
+	defer os.RemoveAll(dir)
+	defer xl.Close()
+
+	db := xl.Module("db")
+	h := xl.LevelHandler()
+
+	elements := []struct {
+		method string
+		body   string
+		code   int
+		result string
+	}{
+		{"GET", "", 200, `{"level":"info","modules":{"db":""}}`},
+		{"PUT", `{"level":"warn","modules":{"db":"debug","rpc":"error"}}`, 200, `{"level":"warn","modules":{"db":"debug","rpc":"error"}}`},
+		{"POST", `{"modules":{"db":""}}`, 200, `{"level":"warn","modules":{"db":"","rpc":"error"}}`},
+		{"PUT", `{"level":"hello"}`, 400, ""},
+		{"PUT", `{"level":"debug","modules":{"db":"hello"}}`, 400, ""},
+		{"PUT", `{"modules":{"":"info"}}`, 400, ""},
+		{"PUT", `level=debug`, 400, ""},
+		{"DELETE", "", 405, ""},
+		{"GET", "", 200, `{"level":"warn","modules":{"db":"","rpc":"error"}}`},
+	}
+
+	for _, element := range elements {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(element.method, "/admin/log", strings.NewReader(element.body)))
+		xassert.Equal(t, w.Code, element.code)
+		if element.code == 405 {
+			xassert.Equal(t, w.Header().Get("Allow"), "GET, PUT, POST")
+		}
+		if element.result != "" {
+			var exp, act interface{}
+			xassert.IsNil(t, json.Unmarshal([]byte(element.result), &exp))
+			xassert.IsNil(t, json.Unmarshal(w.Body.Bytes(), &act))
+			xassert.Equal(t, act, exp)
+		}
+	}
+
+	xassert.Equal(t, db.Level(), WARN)
+	xassert.Equal(t, xl.Module("rpc").Level(), ERROR)
+}
@@ -0,0 +1,140 @@
+// overflow.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// This error is returned when a record is dropped because the buffer
+// channel is full.
+var ErrDropped = errors.New("record has been dropped")
+
+// The policies when the buffer channel is full.
+const (
+	overflowBlock      = iota // Block the invoker until the buffer channel has space.
+	overflowDropNewest        // Drop the record being written.
+	overflowDropOldest        // Drop the oldest record in the buffer channel.
+	overflowSample            // Block one of every N records, and drop the others.
+)
+
+var overflowReadable = [...]string{"block", "drop_newest", "drop_oldest", "sample"}
+
+func parseOverflow(str string) (int, error) {
+	for i, s := range overflowReadable {
+		if s == str {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unknown overflow (%s)", str)
+}
+
+// Handle a record which can't be pushed to the full buffer channel. If the
+// 'block' result is true, the record should be pushed by blocking, otherwise
+// it has been pushed or dropped (the error is ErrDropped). The FATAL records
+// and the barriers are never dropped, they're always pushed by blocking.
+func (c *core) overflowed(r record) (block bool, err error) {
+	n := atomic.AddUint64(&c.overflows, 1)
+
+	if r.level == FATAL || r.done != nil {
+		return true, nil
+	}
+
+	switch c.overflow {
+	case overflowDropOldest:
+		// The attempts are bounded, so the invoker is never blocked even if
+		// the other invokers refill the buffer channel at the same time.
+		for i := 0; i <= cap(c.bc); i++ {
+			select {
+			case old, ok := <-c.bc:
+				if !ok {
+					return false, ErrClosed
+				} else if old.done != nil {
+					// A barrier can't be dropped, push it back. It's
+					// behind some newer records now, but the invoker
+					// of 'Sync' method only waits a bit longer.
+					c.requeue(old)
+				} else {
+					atomic.AddUint64(&c.dropped, 1)
+				}
+			default:
+			}
+
+			select {
+			case c.bc <- r:
+				return false, nil
+			default:
+			}
+		}
+	case overflowSample:
+		if n%c.overflowSample == 0 {
+			return true, nil
+		}
+	}
+
+	atomic.AddUint64(&c.dropped, 1)
+	return false, ErrDropped
+}
+
+// Push a barrier back to the buffer channel without blocking. If it fails,
+// the barrier is completed with an error, so the invoker of 'Sync' or 'Reopen'
+// method won't wait forever. The 'done' channel of a barrier is buffered and
+// only receives one result, so completing it never blocks.
+func (c *core) requeue(b record) {
+	defer func() {
+		if x := recover(); x != nil {
+			// The buffer channel has been closed.
+			b.done <- ErrClosed
+		}
+	}()
+
+	select {
+	case c.bc <- b:
+	default:
+		b.done <- ErrDropped
+	}
+}
+
+// Stats contains the statistics of an XLogger.
+type Stats struct {
+	// The number of records dropped because the buffer channel is full.
+	Dropped uint64
+
+	// The number of times the buffer channel is full.
+	Overflows uint64
+
+	// The number of errors occurred when writing records to sinks.
+	WriteErrors uint64
+
+	// The number of records suppressed by the sampling (see 'SampleInterval'
+	// field of XConfig).
+	Sampled uint64
+
+	// The number of records in the buffer channel and its capacity.
+	QueueDepth    int
+	QueueCapacity int
+}
+
+// Stats returns the statistics of the XLogger. All XLoggers derived from
+// the same root share the statistics.
+func (xl *XLogger) Stats() Stats {
+	if xl == nil {
+		return Stats{}
+	}
+
+	return Stats{
+		Dropped:       atomic.LoadUint64(&xl.dropped),
+		Overflows:     atomic.LoadUint64(&xl.overflows),
+		WriteErrors:   atomic.LoadUint64(&xl.writeErrors),
+		Sampled:       atomic.LoadUint64(&xl.sampled),
+		QueueDepth:    len(xl.bc),
+		QueueCapacity: cap(xl.bc),
+	}
+}
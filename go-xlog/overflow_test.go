@@ -0,0 +1,180 @@
+// overflow_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"bytes"
+	"errors"
+	"github.com/X-Plan/xgo/go-xassert"
+	"os"
+	"strings"
+	"testing"
+)
+
+// blockWriter blocks the flush routine until it's released.
+type blockWriter struct {
+	entered chan int
+	release chan int
+	buf     bytes.Buffer
+}
+
+func (bw *blockWriter) Write(b []byte) (int, error) {
+	bw.entered <- 1
+	<-bw.release
+	return bw.buf.Write(b)
+}
+
+func TestOverflow(t *testing.T) {
+	dir := "/tmp/xlog_overflow"
+	defer os.RemoveAll(dir)
+
+	elements := []struct {
+		overflow string
+		lines    string
+		dropped  uint64
+		err      error
+	}{
+		{"drop_newest", "0 1 2", 1, ErrDropped},
+		{"drop_oldest", "0 2 3", 1, nil},
+		{"sample", "0 1 2", 1, ErrDropped},
+	}
+
+	for _, element := range elements {
+		bw := &blockWriter{entered: make(chan int), release: make(chan int)}
+		xl, err := New(&XConfig{
+			Dir:            dir,
+			Level:          INFO,
+			BufferSize:     2,
+			Overflow:       element.overflow,
+			OverflowSample: 2,
+			Encoder:        captureEncoder{},
+			Sinks:          []SinkConfig{{Type: "writer", Writer: bw}},
+		})
+		xassert.IsNil(t, err)
+
+		xassert.IsNil(t, xl.Info("0"))
+		<-bw.entered // The flush routine is blocked.
+
+		for _, s := range []string{"1", "2"} {
+			xassert.IsNil(t, xl.Info(s))
+		}
+		xassert.Equal(t, xl.Info("3"), element.err)
+
+		stats := xl.Stats()
+		xassert.Equal(t, stats.Dropped, element.dropped)
+		xassert.Equal(t, stats.Overflows, uint64(1))
+		xassert.Equal(t, stats.QueueDepth, 2)
+		xassert.Equal(t, stats.QueueCapacity, 2)
+
+		go func() {
+			for range bw.entered {
+				bw.release <- 1
+			}
+		}()
+		bw.release <- 1
+		xassert.IsNil(t, xl.Close())
+		close(bw.entered)
+
+		xassert.Equal(t, strings.Join(strings.Fields(bw.buf.String()), " "), element.lines)
+		xassert.IsNil(t, os.RemoveAll(dir))
+	}
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, Overflow: "unknown"})
+	xassert.IsNil(t, xl)
+	xassert.NotNil(t, err)
+}
+
+func TestOverflowBarrier(t *testing.T) {
+	newBarrier := func() record {
+		return record{done: make(chan error, 1)}
+	}
+
+	// The buffer channel only holds barriers, they aren't dropped and the
+	// invoker isn't blocked.
+	c := &core{bc: make(chan record, 2), overflow: overflowDropOldest}
+	b1, b2 := newBarrier(), newBarrier()
+	c.bc <- b1
+	c.bc <- b2
+	block, err := c.overflowed(record{b: []byte("x")})
+	xassert.IsFalse(t, block)
+	xassert.Equal(t, err, ErrDropped)
+	xassert.Equal(t, len(c.bc), 2)
+	for i := 0; i < 2; i++ {
+		xassert.NotNil(t, (<-c.bc).done)
+	}
+
+	// The oldest record is dropped, the barrier is kept.
+	b1 = newBarrier()
+	c.bc <- record{b: []byte("old")}
+	c.bc <- b1
+	block, err = c.overflowed(record{b: []byte("new")})
+	xassert.IsFalse(t, block)
+	xassert.IsNil(t, err)
+	xassert.IsTrue(t, (<-c.bc).done == b1.done)
+	xassert.Equal(t, string((<-c.bc).b), "new")
+
+	// The barrier can't be pushed back to the closed buffer channel, it's
+	// completed with ErrClosed.
+	b1 = newBarrier()
+	c.bc <- b1
+	c.bc <- record{b: []byte("old")}
+	close(c.bc)
+	c.requeue(<-c.bc)
+	xassert.Equal(t, <-b1.done, ErrClosed)
+
+	// The barrier can't be pushed back to the full buffer channel, it's
+	// completed with ErrDropped.
+	c = &core{bc: make(chan record, 1), overflow: overflowDropOldest}
+	b1 = newBarrier()
+	c.bc <- record{b: []byte("old")}
+	c.requeue(b1)
+	xassert.Equal(t, <-b1.done, ErrDropped)
+
+	// The FATAL records and the barriers are pushed by blocking under any
+	// policy, the records in the buffer channel are kept.
+	for _, overflow := range []int{overflowDropNewest, overflowDropOldest, overflowSample} {
+		c = &core{bc: make(chan record, 1), overflow: overflow, overflowSample: 2}
+		c.bc <- record{level: INFO, b: []byte("old")}
+		for _, r := range []record{{level: FATAL, b: []byte("fatal")}, newBarrier()} {
+			block, err = c.overflowed(r)
+			xassert.IsTrue(t, block)
+			xassert.IsNil(t, err)
+		}
+		xassert.Equal(t, len(c.bc), 1)
+		xassert.Equal(t, c.dropped, uint64(0))
+	}
+}
+
+type errorWriter struct{}
+
+func (errorWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestOnError(t *testing.T) {
+	dir := "/tmp/xlog_on_error"
+	defer os.RemoveAll(dir)
+
+	var errs []error
+	xl, err := New(&XConfig{
+		Dir:     dir,
+		Level:   INFO,
+		OnError: func(err error) { errs = append(errs, err) },
+		Sinks:   []SinkConfig{{Type: "writer", Writer: errorWriter{}}},
+	})
+	xassert.IsNil(t, err)
+
+	for i := 0; i < 10; i++ {
+		xassert.IsNil(t, xl.Info("hello"))
+	}
+	xassert.IsNil(t, xl.Close())
+
+	xassert.Equal(t, len(errs), 10)
+	xassert.Equal(t, xl.Stats().WriteErrors, uint64(10))
+	checkLines(t, readLines(t, dir), strings.Split(strings.Repeat("hello ", 10), " ")[:10]...)
+}
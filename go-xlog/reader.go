@@ -0,0 +1,257 @@
+// reader.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogFile describes a log file in a directory.
+type LogFile struct {
+	Dir        string
+	CreateTime time.Time
+	Size       int64
+	Compressed bool // Whether the file is compressed by gzip.
+}
+
+// Path returns the complete path of the log file.
+func (lf LogFile) Path() string {
+	return filepath.Join(lf.Dir, lf.info().Name())
+}
+
+func (lf LogFile) info() fileInfo {
+	return fileInfo{lf.CreateTime, lf.Size, lf.Compressed}
+}
+
+// Open the log file for reading, a compressed file is decompressed
+// transparently. If an uncompressed file has been compressed after listing
+// it, the compressed one will be opened.
+func (lf LogFile) Open() (io.ReadCloser, error) {
+	fp, err := os.Open(lf.Path())
+	if err != nil {
+		if !os.IsNotExist(err) || lf.Compressed {
+			return nil, err
+		}
+		lf.Compressed = true
+		if fp, err = os.Open(lf.Path()); err != nil {
+			return nil, err
+		}
+	}
+
+	if !lf.Compressed {
+		return fp, nil
+	}
+
+	gzr, err := gzip.NewReader(fp)
+	if err != nil {
+		fp.Close()
+		return nil, err
+	}
+	return &gzipFile{gzr, fp}, nil
+}
+
+// Close both the gzip reader and the underlying file.
+type gzipFile struct {
+	*gzip.Reader
+	fp *os.File
+}
+
+func (gf *gzipFile) Close() error {
+	err := gf.Reader.Close()
+	if cerr := gf.fp.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ListFiles returns the log files of a directory covering the time range
+// [start, end) in chronological order. A log file covers the time from its
+// creation time to the creation time of the next log file, so the granularity
+// of the range is a file. The zero value of 'start' or 'end' represents
+// unbounded. The directory isn't created if it doesn't exist, an error is
+// returned instead.
+func ListFiles(dir string, start, end time.Time) ([]LogFile, error) {
+	fiq, err := readFileInfoQueue(dir)
+	if err != nil {
+		return nil, err
+	}
+	fiq.Sort()
+
+	var files []LogFile
+	for i, fi := range *fiq {
+		// A file may exist in both formats when it's being compressed,
+		// the two copies are complete, so we only need one of them.
+		if i > 0 && (*fiq)[i-1].CreateTime.Equal(fi.CreateTime) {
+			continue
+		}
+
+		if !end.IsZero() && !fi.CreateTime.Before(end) {
+			break
+		}
+
+		if !start.IsZero() {
+			// The file ends at the creation time of the next file, the
+			// other copy of the same file must be skipped.
+			j := i + 1
+			for j < fiq.Len() && (*fiq)[j].CreateTime.Equal(fi.CreateTime) {
+				j++
+			}
+			if j < fiq.Len() && !(*fiq)[j].CreateTime.After(start) {
+				continue
+			}
+		}
+
+		files = append(files, LogFile{dir, fi.CreateTime, fi.Size, fi.Compressed})
+	}
+	return files, nil
+}
+
+// Reader streams the records of the log files in a directory in chronological
+// order, the compressed files are decompressed transparently. It's safe to
+// create a Reader on a directory which is being written by an XLogger.
+type Reader struct {
+	// The layout of the timestamps of the records, it should be same as the
+	// 'TimeFormat' field of XConfig. If it's empty, the default layout of
+	// the format of a record will be used (see EncoderConfig).
+	TimeFormat string
+
+	files      []LogFile
+	start, end time.Time
+	cur        io.ReadCloser
+	br         *bufio.Reader
+	pending    []byte // The selected data which hasn't been read.
+}
+
+// NewFileReader returns a Reader which streams the records in the time range
+// [start, end) of the log files in a directory. The zero value of 'start' or
+// 'end' represents unbounded. The records are selected by their timestamps,
+// so the records outside the range in the first and the last files are
+// skipped. The lines without timestamps, for example, the ones written by
+// 'Write' method of XLogger, are always selected if their files cover the
+// range (see 'ListFiles' function).
+func NewFileReader(dir string, start, end time.Time) (*Reader, error) {
+	files, err := ListFiles(dir, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{files: files, start: start, end: end}, nil
+}
+
+func (r *Reader) Read(p []byte) (n int, err error) {
+	for len(r.pending) == 0 {
+		if r.cur == nil {
+			if len(r.files) == 0 {
+				return 0, io.EOF
+			}
+			if r.cur, err = r.files[0].Open(); err != nil {
+				return 0, err
+			}
+			r.files = r.files[1:]
+
+			if r.br == nil {
+				r.br = bufio.NewReader(r.cur)
+			} else {
+				r.br.Reset(r.cur)
+			}
+		}
+
+		var line []byte
+		if line, err = r.br.ReadBytes('\n'); len(line) > 0 && r.selected(line) {
+			r.pending = line
+		}
+
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+		} else if err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// Whether a line is in the time range of the Reader.
+func (r *Reader) selected(line []byte) bool {
+	if r.start.IsZero() && r.end.IsZero() {
+		return true
+	}
+
+	t, ok := recordTime(line, r.TimeFormat)
+	if !ok {
+		return true
+	}
+	return (r.start.IsZero() || !t.Before(r.start)) && (r.end.IsZero() || t.Before(r.end))
+}
+
+// Close the Reader.
+func (r *Reader) Close() error {
+	r.files, r.pending = nil, nil
+	if r.cur != nil {
+		err := r.cur.Close()
+		r.cur = nil
+		return err
+	}
+	return nil
+}
+
+// Get the timestamp of a line written by the builtin encoders, the format is
+// detected automatically. If 'layout' parameter is empty, the default layout
+// of the format will be used. The timestamp without time zone is parsed as
+// local time. The 'ok' result is false if the line has no valid timestamp.
+func recordTime(line []byte, layout string) (t time.Time, ok bool) {
+	var (
+		str string
+		def = time.RFC3339
+	)
+
+	switch {
+	case bytes.HasPrefix(line, []byte("[")):
+		// [time][tag][LEVEL]...
+		i := bytes.IndexByte(line, ']')
+		if i < 0 {
+			return
+		}
+		str, def = string(line[1:i]), textTimeFormat
+	case bytes.HasPrefix(line, []byte(`{"time":`)):
+		// {"time":"...",...}
+		quoted, err := strconv.QuotedPrefix(string(line[len(`{"time":`):]))
+		if err != nil {
+			return
+		}
+		str, _ = strconv.Unquote(quoted)
+	case bytes.HasPrefix(line, []byte("time=")):
+		// time=... level=...
+		rest := string(line[len("time="):])
+		if quoted, err := strconv.QuotedPrefix(rest); err == nil {
+			str, _ = strconv.Unquote(quoted)
+		} else if i := strings.IndexAny(rest, " \n"); i >= 0 {
+			str = rest[:i]
+		} else {
+			str = rest
+		}
+	default:
+		return
+	}
+
+	if layout == "" {
+		layout = def
+	}
+	t, err := time.ParseInLocation(layout, str, time.Local)
+	return t, err == nil
+}
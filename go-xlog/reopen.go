@@ -0,0 +1,158 @@
+// reopen.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"bufio"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+// fileSink writes records to a file with a fixed name, it doesn't rotate the
+// file by itself. The file is rotated by an external tool (such as logrotate),
+// then reopened by 'Reopen' method of XLogger.
+type fileSink struct {
+	path string
+	fp   *os.File
+	buf  *bufio.Writer
+}
+
+// Create a fileSink and bind its path. If this function returns nil error,
+// the path will be unbound when the sink is closed.
+func newFileSink(dir, filename string) (fs *fileSink, err error) {
+	fs = &fileSink{path: filepath.Join(dir, filename)}
+	if err = bindDir(fs.path); err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(fs.path), 0777); err == nil {
+		err = fs.open()
+	}
+
+	if err != nil {
+		unbindDir(fs.path)
+		return nil, err
+	}
+	return fs, nil
+}
+
+func (fs *fileSink) open() (err error) {
+	if fs.fp, err = os.OpenFile(fs.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666); err != nil {
+		return
+	}
+	fs.buf = bufio.NewWriterSize(fs.fp, fileBufferSize)
+	return
+}
+
+func (fs *fileSink) write(r record) error {
+	// The file may be failed to reopen, try again.
+	if fs.fp == nil {
+		if err := fs.open(); err != nil {
+			return err
+		}
+	}
+	_, err := fs.buf.Write(r.b)
+	return err
+}
+
+func (fs *fileSink) flush(sync bool) (err error) {
+	if fs.fp == nil {
+		return nil
+	}
+	if err = fs.buf.Flush(); err == nil && sync {
+		err = fs.fp.Sync()
+	}
+	return
+}
+
+// Close the current file and open the file with the same name again, the
+// buffered data is written to the old file.
+func (fs *fileSink) reopen() error {
+	if err := fs.closeFile(); err != nil {
+		return err
+	}
+	return fs.open()
+}
+
+func (fs *fileSink) closeFile() (err error) {
+	if fs.fp == nil {
+		return nil
+	}
+	err = fs.buf.Flush()
+	if cerr := fs.fp.Close(); err == nil {
+		err = cerr
+	}
+	fs.fp, fs.buf = nil, nil
+	return
+}
+
+func (fs *fileSink) close() error {
+	err := fs.closeFile()
+	unbindDir(fs.path)
+	return err
+}
+
+// A sink which can reopen its destination.
+type reopener interface {
+	reopen() error
+}
+
+// Reopen blocks until the files with fixed names (see 'Filename' field of
+// XConfig) have been reopened, the records enqueued before calling it are
+// written to the old files. It's used to cooperate with an external rotation
+// tool, which renames or removes the old files at first, then calls this
+// method to create new files. The other sinks aren't affected.
+func (xl *XLogger) Reopen() error {
+	return xl.barrier(true)
+}
+
+// Reopen all sinks which support it, it's called by the flush routine.
+func (xl *XLogger) reopenSinks() (first error) {
+	for _, s := range xl.sinks {
+		if ro, ok := s.sink.(reopener); ok {
+			if err := ro.reopen(); err != nil {
+				xl.report(err)
+				if first == nil {
+					first = err
+				}
+			}
+		}
+	}
+	return
+}
+
+// Reopen the files when the process receives SIGHUP signal, until 'hupStop'
+// channel is closed. The signal is registered before this method returns, so
+// the signal won't terminate the process after that.
+func (xl *XLogger) watchSIGHUP() {
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGHUP)
+	xl.hupStop, xl.hupExit = make(chan int), make(chan int)
+
+	go func() {
+		defer close(xl.hupExit)
+		defer signal.Stop(sc)
+		for {
+			select {
+			case <-sc:
+				xl.Reopen()
+			case <-xl.hupStop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop the routine started by 'watchSIGHUP' method and wait for it to exit.
+// It will panic if it's called twice, like closing the buffer channel.
+func (xl *XLogger) unwatchSIGHUP() {
+	close(xl.hupStop)
+	<-xl.hupExit
+}
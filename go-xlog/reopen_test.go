@@ -0,0 +1,91 @@
+// reopen_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReopen(t *testing.T) {
+	dir := "/tmp/xlog_reopen"
+	defer os.RemoveAll(dir)
+
+	// The built-in rotation is disabled.
+	xl, err := New(&XConfig{Dir: dir, Filename: "app.log", Level: INFO, MaxSize: 16, MaxBackups: 1})
+	xassert.IsNil(t, err)
+
+	// A file can't be shared by two XLoggers.
+	_, err = New(&XConfig{Dir: dir, Filename: "app.log", Level: INFO})
+	xassert.NotNil(t, err)
+
+	path := filepath.Join(dir, "app.log")
+	xassert.IsNil(t, xl.Info("first"))
+	xassert.IsNil(t, xl.Sync())
+
+	// Move the file like logrotate, the records are still written to the
+	// old file until reopening it.
+	xassert.IsNil(t, os.Rename(path, path+".1"))
+	xassert.IsNil(t, xl.Info("second"))
+	xassert.IsNil(t, xl.Reopen())
+	xassert.IsNil(t, xl.Info("third"))
+	xassert.IsNil(t, xl.Close())
+	xassert.Equal(t, xl.Reopen(), ErrClosed)
+
+	checkLines(t, readFileLines(t, path+".1"), "first", "second")
+	checkLines(t, readFileLines(t, path), "third")
+
+	var nilxl *XLogger
+	xassert.IsNil(t, nilxl.Reopen())
+}
+
+func TestReopenOnSIGHUP(t *testing.T) {
+	dir := "/tmp/xlog_reopen_sighup"
+	defer os.RemoveAll(dir)
+
+	p, err := os.FindProcess(os.Getpid())
+	xassert.IsNil(t, err)
+
+	xl, err := New(&XConfig{Dir: dir, Filename: "app.log", Level: INFO, ReopenOnSIGHUP: true})
+	xassert.IsNil(t, err)
+
+	path := filepath.Join(dir, "app.log")
+	xassert.IsNil(t, xl.Info("first"))
+	xassert.IsNil(t, xl.Sync())
+	xassert.IsNil(t, os.Rename(path, path+".1"))
+	if err = p.Signal(syscall.SIGHUP); err != nil {
+		xl.Close()
+		t.Skipf("send SIGHUP failed (%s)", err)
+	}
+
+	// Wait for the new file is created.
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, xl.Info("second"))
+	xassert.IsNil(t, xl.Close())
+
+	checkLines(t, readFileLines(t, path+".1"), "first")
+	checkLines(t, readFileLines(t, path), "second")
+}
+
+func readFileLines(t *testing.T, path string) []string {
+	data, err := ioutil.ReadFile(path)
+	xassert.IsNil(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
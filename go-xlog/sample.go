@@ -0,0 +1,128 @@
+// sample.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sampler limits the number of records written by each call site. In each
+// interval, the first 'initial' records of a call site are written, then
+// only one of every 'thereafter' records is written. When an interval ends,
+// a summary record is written for each call site which has suppressed records.
+type sampler struct {
+	initial    uint64
+	thereafter uint64
+	interval   time.Duration
+
+	mtx   sync.Mutex
+	sites map[uintptr]*site
+
+	// Close 'stop' channel to notify the summary routine to exit, and it
+	// closes 'exit' channel after writing the last summaries.
+	once sync.Once
+	stop chan int
+	exit chan int
+}
+
+// The state of a call site in the current interval.
+type site struct {
+	// The XLogger which writes the first record of the call site in the
+	// current interval, the summary record is written by it.
+	xl    *XLogger
+	level int
+
+	count      uint64
+	suppressed uint64
+}
+
+func newSampler(initial, thereafter int, interval time.Duration) *sampler {
+	return &sampler{
+		initial:    uint64(initial),
+		thereafter: uint64(thereafter),
+		interval:   interval,
+		sites:      make(map[uintptr]*site),
+		stop:       make(chan int),
+		exit:       make(chan int),
+	}
+}
+
+// Whether the record written by the call site should be written. FATAL
+// records are never suppressed.
+func (s *sampler) allow(xl *XLogger, pc uintptr, level int) bool {
+	if level == FATAL {
+		return true
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	st := s.sites[pc]
+	if st == nil {
+		st = &site{xl: xl, level: level}
+		s.sites[pc] = st
+	}
+
+	st.count++
+	if st.count <= s.initial ||
+		(s.thereafter > 0 && (st.count-s.initial)%s.thereafter == 0) {
+		return true
+	}
+	st.suppressed++
+	return false
+}
+
+// The summary routine, it starts a new interval periodically.
+func (s *sampler) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.summarize()
+		case <-s.stop:
+			s.summarize()
+			close(s.exit)
+			return
+		}
+	}
+}
+
+// Write the summary records of the last interval and reset all call sites.
+func (s *sampler) summarize() {
+	s.mtx.Lock()
+	sites := s.sites
+	s.sites = make(map[uintptr]*site, len(sites))
+	s.mtx.Unlock()
+
+	for pc, st := range sites {
+		if st.suppressed == 0 {
+			continue
+		}
+
+		e := &Entry{
+			Time:    time.Now(),
+			Level:   st.level,
+			Tag:     st.xl.tag,
+			Caller:  pc2caller(pc),
+			Message: fmt.Sprintf("%d records are suppressed in the last %s", st.suppressed, s.interval),
+			Fields:  st.xl.appendFields([]Field{{"suppressed", st.suppressed}}),
+		}
+		st.xl.write(e)
+	}
+}
+
+// Stop the summary routine and wait for it to exit, it's safe to call this
+// method more than once.
+func (s *sampler) close() {
+	s.once.Do(func() { close(s.stop) })
+	<-s.exit
+}
@@ -0,0 +1,96 @@
+// sample_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	dir := "/tmp/xlog_sample"
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, SampleInterval: "1 second"})
+	xassert.IsNil(t, xl)
+	xassert.NotNil(t, err)
+
+	xl, err = New(&XConfig{Dir: dir, Level: INFO, SampleInitial: -1, SampleInterval: "1s"})
+	xassert.IsNil(t, xl)
+	xassert.NotNil(t, err)
+
+	// The interval is long enough, so the summaries are only written when
+	// the XLogger is closed.
+	xl, err = New(&XConfig{Dir: dir, Level: INFO, SampleInitial: 3, SampleThereafter: 5, SampleInterval: "1h"})
+	xassert.IsNil(t, err)
+
+	for i := 0; i < 20; i++ {
+		xassert.IsNil(t, xl.Error("hot %d", i))
+	}
+	for i := 0; i < 5; i++ {
+		xassert.IsNil(t, xl.Info("cold %d", i))
+	}
+	for i := 0; i < 5; i++ {
+		xassert.IsNil(t, xl.Fatal("fatal %d", i))
+	}
+	xassert.Equal(t, xl.Stats().Sampled, uint64(16))
+	xassert.IsNil(t, xl.Close())
+
+	var hot, cold, fatal, summaries []string
+	for _, line := range readLines(t, dir) {
+		switch {
+		case strings.Contains(line, "suppressed="):
+			summaries = append(summaries, line)
+		case strings.Contains(line, "hot"):
+			hot = append(hot, line)
+		case strings.Contains(line, "cold"):
+			cold = append(cold, line)
+		case strings.Contains(line, "fatal"):
+			fatal = append(fatal, line)
+		}
+	}
+
+	// 0, 1, 2 are the initial records, then one of every five records.
+	checkLines(t, hot, "hot 0", "hot 1", "hot 2", "hot 7", "hot 12", "hot 17")
+	checkLines(t, cold, "cold 0", "cold 1", "cold 2")
+	xassert.Equal(t, len(fatal), 5)
+	xassert.Equal(t, len(summaries), 2)
+	for _, line := range summaries {
+		xassert.Match(t, line, `sample_test\.go\(\d+\)`)
+	}
+}
+
+// The records below the level don't consume the quota of their call sites.
+func TestSampleLevel(t *testing.T) {
+	dir := "/tmp/xlog_sample_level"
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, SampleInitial: 1, SampleInterval: "1h"})
+	xassert.IsNil(t, err)
+	xassert.IsFalse(t, xl.sampling(DEBUG))
+	xassert.IsTrue(t, xl.sampling(INFO))
+
+	pc := callerPC(0)
+	for i := 0; i < 3; i++ {
+		_, err = xl.outputPC(DEBUG, pc, "ignored", nil)
+		xassert.IsNil(t, err)
+	}
+	for _, m := range []string{"info 0", "info 1"} {
+		_, err = xl.outputPC(INFO, pc, m, nil)
+		xassert.IsNil(t, err)
+	}
+	xassert.Equal(t, xl.Stats().Sampled, uint64(1))
+	xassert.IsNil(t, xl.Close())
+
+	lines := readLines(t, dir)
+	xassert.Equal(t, len(lines), 2)
+	xassert.IsTrue(t, strings.HasSuffix(lines[0], "info 0"))
+	xassert.IsTrue(t, strings.Contains(lines[1], "1 records are suppressed"))
+}
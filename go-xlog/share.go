@@ -0,0 +1,77 @@
+// share.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Several processes may share a directory, for example, the old and the new
+// process overlap when a server restarts gracefully. They are coordinated by
+// two kinds of advisory locks:
+//
+//  1. The lock file of the directory serializes the operations changing the
+//     set of log files (cleanup, opening, creating and committing compressed
+//     files), so these operations of different processes never interleave.
+//  2. Each process holds the lock of its active log file and the log files
+//     being compressed. Other processes won't append to, delete or compress
+//     a locked file.
+//
+// The advisory locks are only supported on unix-like platforms.
+
+// The name of the lock file, it doesn't match the standard file name, so
+// it's ignored by the cleanup work.
+const lockName = ".xlog.lock"
+
+// The lock of a file is held by another open file.
+var errLocked = errors.New("file is locked")
+
+// Open the lock file of a directory.
+func openLockFile(dir string) (*os.File, error) {
+	return os.OpenFile(filepath.Join(dir, lockName), os.O_RDWR|os.O_CREATE, 0666)
+}
+
+// Whether a log file is active, which means it's being written by an XLogger
+// (maybe in another process).
+func isActive(dir string, fi fileInfo) bool {
+	if fi.Compressed {
+		return false
+	}
+
+	fp, err := os.Open(filepath.Join(dir, fi.Name()))
+	if err != nil {
+		return false
+	}
+	// Closing the file releases the lock if it's acquired.
+	defer fp.Close()
+	return flock(fp, false) == errLocked
+}
+
+// Lock the directory of the sink, 'mtx' serializes the routines of this
+// process, and the lock file serializes the processes.
+func (ds *dirSink) lock() error {
+	var err error
+	ds.mtx.Lock()
+	if ds.lk == nil {
+		ds.lk, err = openLockFile(ds.dir)
+	}
+	if err == nil {
+		err = flock(ds.lk, true)
+	}
+	if err != nil {
+		ds.mtx.Unlock()
+	}
+	return err
+}
+
+func (ds *dirSink) unlock() {
+	funlock(ds.lk)
+	ds.mtx.Unlock()
+}
@@ -0,0 +1,122 @@
+// share_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package xlog
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// The lock of a file is associated with an open file, so the locks in one
+// process behave as they're held by different processes.
+func TestActiveFile(t *testing.T) {
+	dir := "/tmp/xlog_active_file"
+	defer os.RemoveAll(dir)
+	xassert.IsNil(t, os.MkdirAll(dir, 0777))
+
+	f, err := createFile(dir, time.Now())
+	xassert.IsNil(t, err)
+	fi := fileInfo{CreateTime: f.CreateTime}
+	xassert.IsTrue(t, isActive(dir, fi))
+
+	// The active file can't be appended and deleted.
+	nf, err := openFile(dir)
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, nf)
+	xassert.IsNil(t, cleanup(dir, 0, 1, 0))
+	_, err = os.Stat(getName(dir, f.CreateTime))
+	xassert.IsNil(t, err)
+
+	xassert.IsNil(t, f.Close())
+	xassert.IsFalse(t, isActive(dir, fi))
+
+	nf, err = openFile(dir)
+	xassert.IsNil(t, err)
+	xassert.NotNil(t, nf)
+	xassert.IsTrue(t, nf.CreateTime.Equal(f.CreateTime))
+	xassert.IsNil(t, nf.Close())
+
+	xassert.IsNil(t, cleanup(dir, 0, 1, 0))
+	_, err = os.Stat(getName(dir, f.CreateTime))
+	xassert.IsTrue(t, os.IsNotExist(err))
+}
+
+func TestShareDir(t *testing.T) {
+	dir := "/tmp/xlog_share_dir"
+	defer os.RemoveAll(dir)
+	xassert.IsNil(t, os.MkdirAll(dir, 0777))
+
+	// The active file of the old process.
+	old, err := createFile(dir, time.Now())
+	xassert.IsNil(t, err)
+	_, err = old.Write([]byte("old\n"))
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, old.Flush(false))
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, MaxSize: 64, MaxBackups: 1, Compress: true})
+	xassert.IsNil(t, err)
+	for i := 0; i < 10; i++ {
+		xassert.IsNil(t, xl.Info("new %d", i))
+	}
+	xassert.IsNil(t, xl.Close())
+
+	// The old file is neither appended, deleted nor compressed.
+	data, err := ioutil.ReadFile(getName(dir, old.CreateTime))
+	xassert.IsNil(t, err)
+	xassert.Equal(t, string(data), "old\n")
+
+	_, err = old.Write([]byte("old again\n"))
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, old.Close())
+	data, err = ioutil.ReadFile(getName(dir, old.CreateTime))
+	xassert.IsNil(t, err)
+	xassert.Equal(t, string(data), "old\nold again\n")
+}
+
+func TestCompressLocked(t *testing.T) {
+	dir := "/tmp/xlog_compress_locked"
+	defer os.RemoveAll(dir)
+	xassert.IsNil(t, os.MkdirAll(dir, 0777))
+
+	tm := time.Now().Add(-time.Hour)
+	xassert.IsNil(t, ioutil.WriteFile(getName(dir, tm), []byte("line\n"), 0666))
+
+	// The file is being compressed by another routine or process.
+	fp, err := os.Open(getName(dir, tm))
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, flock(fp, false))
+	_, err = compressFile(dir, tm)
+	xassert.Equal(t, err, errLocked)
+	xassert.IsNil(t, fp.Close())
+
+	// The temporary file of the failed compression isn't created.
+	names, err := filepath.Glob(getName(dir, tm) + compressSuffix + "*")
+	xassert.IsNil(t, err)
+	xassert.Equal(t, len(names), 0)
+
+	tmp, err := compressFile(dir, tm)
+	xassert.IsNil(t, err)
+	xassert.Equal(t, tmp, getName(dir, tm)+compressSuffix+"."+strconv.Itoa(os.Getpid())+".tmp")
+	xassert.IsFalse(t, isValidName(filepath.Base(tmp)))
+	xassert.IsNil(t, commitCompressed(dir, tm, tmp))
+
+	r, err := NewFileReader(dir, time.Time{}, time.Time{})
+	xassert.IsNil(t, err)
+	data, err := ioutil.ReadAll(r)
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, r.Close())
+	xassert.Equal(t, string(data), "line\n")
+}
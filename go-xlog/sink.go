@@ -0,0 +1,424 @@
+// sink.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A sink is a destination of records. All methods of a sink are only called
+// by the flush routine, so they don't need to be safe for concurrent use.
+type sink interface {
+	// Write a record, zero level represents the record comes from the
+	// 'Write' method of XLogger.
+	write(r record) error
+
+	// Flush the buffered data, if 'sync' parameter is true, the data
+	// should also be committed to the stable storage.
+	flush(sync bool) error
+
+	// Flush the buffered data and release resources.
+	close() error
+}
+
+// Only the records whose priority is higher than or equal to the level
+// are written to the sink. Zero level represents the sink follows the
+// level of the module writing a record.
+type leveledSink struct {
+	sink
+	level int
+}
+
+// Get the level of the sink, the 'threshold' parameter is the level of
+// the module writing a record.
+func (ls leveledSink) threshold(threshold int) int {
+	if ls.level != 0 {
+		return ls.level
+	}
+	return threshold
+}
+
+// Whether a record should be written to the sink. The records of 'Write'
+// method have no level, they're only written to the directories (including
+// the main one), never to the standard streams, writers or syslog.
+func (ls leveledSink) accept(r record) bool {
+	if r.level == 0 {
+		switch ls.sink.(type) {
+		case *dirSink, *fileSink:
+			return true
+		}
+		return false
+	}
+	return r.level <= ls.threshold(r.threshold)
+}
+
+// Create a sink by the configure.
+func newSink(scfg *SinkConfig) (ls leveledSink, err error) {
+	if scfg.Level != 0 {
+		if scfg.Level < FATAL || scfg.Level > DEBUG {
+			return ls, fmt.Errorf("Level is invalid")
+		}
+		ls.level = scfg.Level
+	}
+
+	switch scfg.Type {
+	case "dir":
+		if scfg.Dir == "" {
+			return ls, fmt.Errorf("Dir is empty")
+		}
+		ls.sink, err = newFileOrDirSink(scfg.Dir, scfg.Filename, scfg.MaxSize, scfg.MaxBackups, scfg.MaxAge, scfg.Rotate, scfg.Compress)
+	case "stdout":
+		ls.sink = writerSink{os.Stdout}
+	case "stderr":
+		ls.sink = writerSink{os.Stderr}
+	case "writer":
+		if scfg.Writer == nil {
+			return ls, fmt.Errorf("Writer is nil")
+		}
+		ls.sink = writerSink{scfg.Writer}
+	case "syslog":
+		ls.sink, err = newSyslogSink(scfg.Network, scfg.Address)
+	default:
+		err = fmt.Errorf("unknown type (%s)", scfg.Type)
+	}
+	return
+}
+
+// Create the sink of a directory. If 'filename' parameter isn't empty, the
+// records are written to a file with the fixed name in the directory, and the
+// other parameters are ignored, because the file won't be rotated by itself.
+func newFileOrDirSink(dir, filename string, ms, mb int64, ma, rotate string, compress bool) (sink, error) {
+	// Avoid returning a nil pointer wrapped in a non-nil interface.
+	if filename != "" {
+		fs, err := newFileSink(dir, filename)
+		if err != nil {
+			return nil, err
+		}
+		return fs, nil
+	}
+
+	ds, err := newDirSink(dir, ms, mb, ma, rotate, compress)
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// dirSink writes records to a directory, and rotates log files in it.
+type dirSink struct {
+	dir    string
+	ms     int64
+	mb     int64
+	ma     time.Duration
+	rotate int
+	f      *file
+
+	// Whether compressing the closed log files in the background. 'wg' is
+	// used to wait for all background compressions are done, and 'cerr'
+	// stores the latest error of them.
+	compress bool
+	wg       sync.WaitGroup
+	cerr     chan error
+
+	// Serialize the operations changing the set of log files, see 'lock'
+	// method for details. The lock file is opened when it's used at first,
+	// so an XLogger writing nothing doesn't leave any file.
+	mtx sync.Mutex
+	lk  *os.File
+}
+
+// Create a dirSink and bind its directory. If this function returns nil
+// error, the directory will be unbound when the sink is closed.
+func newDirSink(dir string, ms, mb int64, ma, rotate string, compress bool) (ds *dirSink, err error) {
+	if ms < 0 {
+		return nil, fmt.Errorf("MaxSize is invalid")
+	}
+
+	if mb < 0 {
+		return nil, fmt.Errorf("MaxBackups is invalid")
+	}
+
+	ds = &dirSink{dir: dir, ms: ms, mb: mb, compress: compress, cerr: make(chan error, 1)}
+	if ma != "" {
+		if ds.ma, err = time.ParseDuration(ma); err != nil {
+			return nil, err
+		}
+	}
+
+	if rotate != "" {
+		if ds.rotate, err = parseRotate(rotate); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = bindDir(dir); err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(dir, 0777); err == nil {
+		// Check whether user can write to this directory.
+		err = isWritable(dir)
+	}
+
+	if err != nil {
+		unbindDir(dir)
+		return nil, err
+	}
+	return ds, nil
+}
+
+// This function is not smiliar to 'Write' function of XLogger, it's used
+// to write data to the file.
+func (ds *dirSink) write(r record) error {
+	var err error
+	// Init
+	if ds.f == nil {
+		if err = ds.open(); err != nil {
+			return err
+		}
+	}
+
+	// The size of current log file exceeds the limit, or the record belongs
+	// to a new period.
+	if ds.f == nil || (ds.ms > 0 && ds.f.Size >= ds.ms) || ds.expired(r.t) {
+		// We must ensure that the data of old log file has been persisted
+		// before using the new log file.
+		if ds.f != nil {
+			if err = ds.f.Close(); err != nil {
+				return err
+			}
+			if ds.compress {
+				ds.compressAsync(ds.f.CreateTime)
+			}
+		}
+
+		if err = ds.create(r.t); err != nil {
+			return err
+		}
+	}
+
+	if _, err = ds.f.Write(r.b); err != nil {
+		return err
+	}
+
+	select {
+	case err = <-ds.cerr:
+	default:
+	}
+	return err
+}
+
+// Open the latest log file of the directory, it's called when writing the
+// first record. If the latest log file is being written by another process,
+// the current file is still nil.
+func (ds *dirSink) open() (err error) {
+	if err = ds.lock(); err != nil {
+		return
+	}
+	defer ds.unlock()
+
+	if err = cleanup(ds.dir, ds.ma, ds.mb, ds.mb); err != nil {
+		return
+	}
+
+	if ds.f, err = openFile(ds.dir); err != nil {
+		return
+	}
+
+	if ds.compress {
+		err = ds.compressBackups()
+	}
+	return
+}
+
+// Create a new log file as the current file, the 't' parameter is the time
+// of the first record of the new file. When rotating log files by time, the
+// creation time of the file is the start of the record's period, so the file
+// belongs to the same period as its records.
+func (ds *dirSink) create(t time.Time) (err error) {
+	if err = ds.lock(); err != nil {
+		return
+	}
+	defer ds.unlock()
+
+	// Because we will create a new file, so the number of files decreases one.
+	if err = cleanup(ds.dir, ds.ma, ds.mb, ds.mb-1); err != nil {
+		return
+	}
+
+	if ds.rotate != rotateNone {
+		t = periodStart(t, ds.rotate)
+	}
+
+	// The log files of one period are ordered by their creation times, even
+	// if the records are enqueued out of order.
+	if ds.f != nil && !t.After(ds.f.CreateTime) &&
+		periodStart(t, ds.rotate).Equal(periodStart(ds.f.CreateTime, ds.rotate)) {
+		t = ds.f.CreateTime.Add(time.Nanosecond)
+	}
+
+	ds.f, err = createFile(ds.dir, t)
+	return
+}
+
+// Whether the record with the given time should be written to a new file,
+// because the current file belongs to another period. A record belonging to
+// an earlier period (two routines may enqueue records out of order) is also
+// written to a new file of its own period, so each log file only holds the
+// records of one period.
+func (ds *dirSink) expired(t time.Time) bool {
+	return ds.rotate != rotateNone && ds.f != nil &&
+		!periodStart(t, ds.rotate).Equal(periodStart(ds.f.CreateTime, ds.rotate))
+}
+
+func (ds *dirSink) flush(sync bool) error {
+	if ds.f != nil {
+		return ds.f.Flush(sync)
+	}
+	return nil
+}
+
+func (ds *dirSink) close() (err error) {
+	if ds.f != nil {
+		err = ds.f.Close()
+	}
+	ds.wg.Wait()
+	if ds.lk != nil {
+		ds.lk.Close()
+	}
+	unbindDir(ds.dir)
+	return
+}
+
+// The policies of rotating log files by time.
+const (
+	rotateNone   = iota // Only rotate log files by size.
+	rotateHourly        // Each log file only holds the records of one hour.
+	rotateDaily         // Each log file only holds the records of one day.
+)
+
+var rotateReadable = [...]string{"none", "hourly", "daily"}
+
+func parseRotate(str string) (int, error) {
+	for i, s := range rotateReadable {
+		if s == str {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unknown rotate (%s)", str)
+}
+
+// Get the start time of the period which the time belongs to.
+func periodStart(t time.Time, rotate int) time.Time {
+	switch rotate {
+	case rotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case rotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// writerSink writes records to an io.Writer, the writer won't be closed
+// by the sink.
+type writerSink struct {
+	w io.Writer
+}
+
+func (ws writerSink) write(r record) error {
+	_, err := ws.w.Write(r.b)
+	return err
+}
+
+func (ws writerSink) flush(sync bool) error {
+	return nil
+}
+
+func (ws writerSink) close() error {
+	return nil
+}
+
+// The severities of syslog (RFC 5424) corresponding to the levels, the
+// first one is never used because the records of 'Write' method aren't
+// written to syslog.
+var syslogSeverities = [...]int{6, 2, 3, 4, 6, 7}
+
+// The facility of the records, it's 'user-level messages'.
+const syslogFacility = 1
+
+// syslogSink writes records to a syslog-style socket, each record is
+// prefixed with its priority, such as '<11>'.
+type syslogSink struct {
+	network string
+	address string
+	conn    net.Conn
+}
+
+// Create a syslogSink. If the network and the address are both empty,
+// the local syslog daemon will be used.
+func newSyslogSink(network, address string) (ss *syslogSink, err error) {
+	ss = &syslogSink{network: network, address: address}
+	if err = ss.connect(); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+func (ss *syslogSink) connect() (err error) {
+	if ss.network != "" || ss.address != "" {
+		ss.conn, err = net.Dial(ss.network, ss.address)
+		return
+	}
+
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+			if ss.conn, err = net.Dial(network, path); err == nil {
+				return
+			}
+		}
+	}
+	return fmt.Errorf("unix syslog delivery error")
+}
+
+func (ss *syslogSink) write(r record) (err error) {
+	pri := "<" + strconv.Itoa(syslogFacility*8+syslogSeverities[r.level]) + ">"
+	data := make([]byte, 0, len(pri)+len(r.b))
+	data = append(append(data, pri...), r.b...)
+
+	// Reconnect once if the connection is broken.
+	if ss.conn != nil {
+		if _, err = ss.conn.Write(data); err == nil {
+			return
+		}
+		ss.conn.Close()
+		ss.conn = nil
+	}
+
+	if err = ss.connect(); err != nil {
+		return
+	}
+	_, err = ss.conn.Write(data)
+	return
+}
+
+func (ss *syslogSink) flush(sync bool) error {
+	return nil
+}
+
+func (ss *syslogSink) close() error {
+	if ss.conn != nil {
+		return ss.conn.Close()
+	}
+	return nil
+}
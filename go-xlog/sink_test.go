@@ -0,0 +1,206 @@
+// sink_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"bytes"
+	"github.com/X-Plan/xgo/go-xassert"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSinks(t *testing.T) {
+	var (
+		dir    = "/tmp/xlog_sinks"
+		errDir = "/tmp/xlog_sinks/error"
+		buf    = &bytes.Buffer{}
+	)
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{
+		Dir:   dir,
+		Tag:   "sinks",
+		Level: INFO,
+		Sinks: []SinkConfig{
+			{Type: "dir", Dir: errDir, Level: ERROR},
+			{Type: "writer", Writer: buf, Level: DEBUG},
+		},
+	})
+	xassert.IsNil(t, err)
+
+	xassert.IsNil(t, xl.Error("error"))
+	xassert.IsNil(t, xl.Warn("warn"))
+	xassert.IsNil(t, xl.Info("info"))
+	xassert.IsNil(t, xl.Debug("debug"))
+	_, err = xl.Write([]byte("raw\n"))
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, xl.Close())
+
+	checkLines(t, readLines(t, dir), "error", "warn", "info", "raw")
+	checkLines(t, readLines(t, errDir), "error", "raw")
+	// The records of 'Write' method aren't written to the writer sink.
+	checkLines(t, strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), "error", "warn", "info", "debug")
+
+	// The directories have been unbound.
+	xl, err = New(&XConfig{Dir: errDir, Level: INFO})
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, xl.Close())
+}
+
+func TestInvalidSinks(t *testing.T) {
+	dir := "/tmp/xlog_invalid_sinks"
+	defer os.RemoveAll(dir)
+
+	elements := []SinkConfig{
+		{Type: "unknown"},
+		{Type: "dir"},
+		{Type: "dir", Dir: dir},
+		{Type: "writer"},
+		{Type: "stdout", Level: 10},
+		{Type: "syslog", Network: "tcp", Address: "127.0.0.1:0"},
+	}
+
+	for _, element := range elements {
+		xl, err := New(&XConfig{Dir: dir, Level: INFO, Sinks: []SinkConfig{element}})
+		xassert.IsNil(t, xl)
+		xassert.NotNil(t, err)
+	}
+
+	// The main directory has been unbound after failing.
+	xl, err := New(&XConfig{Dir: dir, Level: INFO})
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, xl.Close())
+}
+
+func TestSyslogSink(t *testing.T) {
+	dir := "/tmp/xlog_syslog"
+	defer os.RemoveAll(dir)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	xassert.IsNil(t, err)
+	defer conn.Close()
+
+	xl, err := New(&XConfig{
+		Dir:   dir,
+		Tag:   "syslog",
+		Level: INFO,
+		Sinks: []SinkConfig{{Type: "syslog", Network: "udp", Address: conn.LocalAddr().String()}},
+	})
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, xl.Warn("hello"))
+	xassert.IsNil(t, xl.Close())
+
+	b := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(b)
+	xassert.IsNil(t, err)
+	xassert.Match(t, string(b[:n]), `^<12>\[.+\]\[syslog\]\[WARN\].*:hello\n$`)
+}
+
+func TestPeriodStart(t *testing.T) {
+	tm := time.Date(2018, 8, 24, 10, 20, 30, 100, time.Local)
+	xassert.Equal(t, periodStart(tm, rotateNone), time.Time{})
+	xassert.Equal(t, periodStart(tm, rotateHourly), time.Date(2018, 8, 24, 10, 0, 0, 0, time.Local))
+	xassert.Equal(t, periodStart(tm, rotateDaily), time.Date(2018, 8, 24, 0, 0, 0, 0, time.Local))
+}
+
+func TestRotate(t *testing.T) {
+	dir := "/tmp/xlog_rotate"
+	defer os.RemoveAll(dir)
+
+	elements := []struct {
+		rotate string
+		files  int
+	}{
+		{"", 1},
+		{"none", 1},
+		{"hourly", 2},
+		{"daily", 2},
+	}
+
+	for _, element := range elements {
+		// Simulate a log file created in the previous day.
+		xassert.IsNil(t, os.MkdirAll(dir, 0777))
+		old := time.Now().Add(-25 * time.Hour)
+		xassert.IsNil(t, ioutil.WriteFile(getName(dir, old), []byte("old\n"), 0666))
+
+		xl, err := New(&XConfig{Dir: dir, Level: INFO, Rotate: element.rotate})
+		xassert.IsNil(t, err)
+		xassert.IsNil(t, xl.Info("new 1"))
+		xassert.IsNil(t, xl.Info("new 2"))
+		xassert.IsNil(t, xl.Close())
+
+		fiq, err := createFileInfoQueue(dir)
+		xassert.IsNil(t, err)
+		xassert.Equal(t, fiq.Len(), element.files)
+		checkLines(t, readLines(t, dir), "old", "new 1", "new 2")
+		xassert.IsNil(t, os.RemoveAll(dir))
+	}
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, Rotate: "weekly"})
+	xassert.IsNil(t, xl)
+	xassert.NotNil(t, err)
+}
+
+// The records are placed by their timestamps instead of the time of writing
+// them, including the ones enqueued out of order around a boundary.
+func TestRotateBoundary(t *testing.T) {
+	dir := "/tmp/xlog_rotate_boundary"
+	defer os.RemoveAll(dir)
+	os.RemoveAll(dir)
+
+	ds, err := newDirSink(dir, 0, 0, "", "hourly", false)
+	xassert.IsNil(t, err)
+
+	p := time.Date(2018, 8, 24, 10, 0, 0, 0, time.Local)
+	q := p.Add(time.Hour)
+	records := []struct {
+		t time.Time
+		b string
+	}{
+		{p.Add(59 * time.Minute), "a\n"},
+		{q.Add(time.Second), "b\n"},
+		{p.Add(59*time.Minute + 59*time.Second), "c\n"}, // Enqueued after the boundary.
+		{q.Add(2 * time.Second), "d\n"},
+	}
+	for _, r := range records {
+		xassert.IsNil(t, ds.write(record{level: INFO, t: r.t, b: []byte(r.b)}))
+	}
+	xassert.IsNil(t, ds.close())
+
+	fiq, err := createFileInfoQueue(dir)
+	xassert.IsNil(t, err)
+	fiq.Sort()
+
+	elements := []struct {
+		ct   time.Time
+		data string
+	}{
+		{p, "a\n"},
+		{p.Add(time.Nanosecond), "c\n"},
+		{q, "b\n"},
+		{q.Add(time.Nanosecond), "d\n"},
+	}
+	xassert.Equal(t, fiq.Len(), len(elements))
+	for i, element := range elements {
+		xassert.IsTrue(t, (*fiq)[i].CreateTime.Equal(element.ct))
+		data, err := ioutil.ReadFile(getName(dir, element.ct))
+		xassert.IsNil(t, err)
+		xassert.Equal(t, string(data), element.data)
+	}
+}
+
+func checkLines(t *testing.T, lines []string, messages ...string) {
+	xassert.Equal(t, len(lines), len(messages))
+	for i, message := range messages {
+		xassert.IsTrue(t, strings.HasSuffix(lines[i], message))
+	}
+}
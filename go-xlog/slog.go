@@ -0,0 +1,136 @@
+// slog.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+//go:build go1.21
+// +build go1.21
+
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogHandler is an implementation of slog.Handler backed by an XLogger, so
+// the libraries using log/slog package can write records to the XLogger:
+//
+//	logger := slog.New(xlog.NewSlogHandler(xl))
+//	logger.Info("login", "user", "blinklv")
+//	// [2018-08-24 10:00:00][tag][INFO]:login user=blinklv
+//
+// The attributes are converted to fields, the keys of the attributes in a
+// group are prefixed with the group name, such as 'group.key'. The fields
+// carried by the context (see 'NewContext' function) are also included.
+// Unlike the methods of XLogger, the records which aren't accepted by any
+// sink are discarded instead of being written to the standard output, so
+// 'Enabled' and 'Handle' methods follow the same rule.
+type SlogHandler struct {
+	xl     *XLogger
+	group  string // The prefix of keys, it ends with '.' if it isn't empty.
+	fields []Field
+}
+
+// NewSlogHandler returns a SlogHandler backed by the XLogger.
+func NewSlogHandler(xl *XLogger) *SlogHandler {
+	return &SlogHandler{xl: xl}
+}
+
+// Convert a level of slog to the level of XLogger. The levels higher than
+// slog.LevelError are converted to FATAL.
+func slogLevel(l slog.Level) int {
+	switch {
+	case l > slog.LevelError:
+		return FATAL
+	case l >= slog.LevelError:
+		return ERROR
+	case l >= slog.LevelWarn:
+		return WARN
+	case l >= slog.LevelInfo:
+		return INFO
+	}
+	return DEBUG
+}
+
+// Enabled reports whether at least one sink of the XLogger accepts the records
+// of the level.
+func (h *SlogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	if h.xl == nil {
+		return false
+	}
+	return h.xl.accept(slogLevel(l), h.xl.Level())
+}
+
+// Handle writes a slog.Record to the XLogger if it's enabled, the location
+// and the time of the record are kept. If the time of the record is zero,
+// the current time will be used.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.Enabled(ctx, r.Level) {
+		return nil
+	}
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	cfields := FieldsFromContext(ctx)
+	fields := make([]Field, 0, len(cfields)+len(h.fields)+r.NumAttrs())
+	fields = append(append(fields, cfields...), h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.group, a)
+		return true
+	})
+
+	_, err := h.xl.outputAt(t, slogLevel(r.Level), r.PC, r.Message, fields)
+	return err
+}
+
+// WithAttrs returns a new SlogHandler whose fields consist of both the fields
+// of the handler and the given attributes.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make([]Field, len(h.fields), len(h.fields)+len(attrs))
+	copy(fields, h.fields)
+	for _, a := range attrs {
+		fields = appendAttr(fields, h.group, a)
+	}
+	return &SlogHandler{xl: h.xl, group: h.group, fields: fields}
+}
+
+// WithGroup returns a new SlogHandler, the keys of the subsequent attributes
+// are prefixed with the group name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{xl: h.xl, group: h.group + name + ".", fields: h.fields}
+}
+
+// Convert an attribute to fields and append them, the attributes of a group
+// are flattened. An empty attribute is ignored.
+func appendAttr(fields []Field, group string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		// The attributes of a group without key are inlined.
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, group, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{group + a.Key, a.Value.Any()})
+}
@@ -0,0 +1,58 @@
+// slog_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+//go:build go1.21
+// +build go1.21
+
+package xlog
+
+import (
+	"context"
+	"github.com/X-Plan/xgo/go-xassert"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	dir := "/tmp/xlog_slog"
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, Location: "always"})
+	xassert.IsNil(t, err)
+
+	h := NewSlogHandler(xl)
+	xassert.IsFalse(t, h.Enabled(context.Background(), slog.LevelDebug))
+	xassert.IsTrue(t, h.Enabled(context.Background(), slog.LevelInfo))
+
+	logger := slog.New(h)
+	ctx := NewContext(context.Background(), RequestIDKey, "9b2c0e1d")
+	logger.Info("login", "user", "blinklv")
+	logger.Debug("ignored")
+	logger.With("id", 10).WithGroup("req").ErrorContext(ctx, "failed", "path", "/", slog.Group("", "code", 500))
+	logger.Log(context.Background(), slog.LevelError+4, "fatal", slog.Group("g", slog.Int("n", 1)))
+
+	// The forwarded records keep their own time, and the records which
+	// aren't enabled are discarded.
+	tm := time.Date(2018, 8, 24, 10, 20, 30, 0, time.Local)
+	xassert.IsNil(t, h.Handle(context.Background(), slog.NewRecord(tm, slog.LevelWarn, "forwarded", 0)))
+	xassert.IsNil(t, h.Handle(context.Background(), slog.NewRecord(tm, slog.LevelDebug, "discarded", 0)))
+	xassert.IsNil(t, h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelWarn, "now", 0)))
+	xassert.IsNil(t, xl.Close())
+
+	lines := readLines(t, dir)
+	xassert.Equal(t, len(lines), 5)
+	xassert.IsTrue(t, strings.HasPrefix(lines[3], "[2018-08-24 10:20:30]["))
+	xassert.IsTrue(t, strings.HasSuffix(lines[3], ":forwarded"))
+	xassert.IsFalse(t, strings.HasPrefix(lines[4], "[0001-01-01"))
+	xassert.IsTrue(t, strings.HasSuffix(lines[4], ":now"))
+	xassert.Match(t, lines[0], `\[INFO\]\[slog_test\.go\(\d+\) - .*TestSlogHandler\]:login user=blinklv$`)
+	xassert.Match(t, lines[1], `\[ERROR\]\[slog_test\.go\(\d+\) - .*TestSlogHandler\]:failed request_id=9b2c0e1d id=10 req.path=/ req.code=500$`)
+	xassert.Match(t, lines[2], `\[FATAL\]\[slog_test\.go\(\d+\) - .*TestSlogHandler\]:fatal g.n=1$`)
+}
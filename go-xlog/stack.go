@@ -0,0 +1,132 @@
+// stack.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// The max number of frames in a stack trace.
+const maxStackDepth = 64
+
+// Get the stack trace of the current goroutine, it begins with the frame of
+// the program counter returned by 'callerPC' function. The format of each
+// frame is same as the one of runtime/debug.Stack function:
+//
+//	main.main
+//		/path/to/main.go:12
+//
+// The trace has multiple lines, but the builtin encoders escape it, so a
+// record is still one line.
+func callerStack(pc uintptr) string {
+	pcs := make([]uintptr, maxStackDepth)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	for i := range pcs {
+		if pcs[i] == pc {
+			pcs = pcs[i:]
+			break
+		}
+	}
+
+	var (
+		lines  []string
+		frames = runtime.CallersFrames(pcs)
+	)
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "runtime.goexit" {
+			lines = append(lines, frame.Function, "\t"+frame.File+":"+strconv.Itoa(frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// The messages of the errors wrapped by an error. The JSON encoder encodes
+// it to an array, and the other encoders encode it to 'cause; cause; cause'.
+type causeList []string
+
+func (cl causeList) String() string {
+	return strings.Join(cl, "; ")
+}
+
+// Get the messages of the errors wrapped by an error, the ones wrapped by
+// 'Unwrap() error' method (such as fmt.Errorf with '%w') and 'Unwrap() []error'
+// method (such as errors.Join) are both included. The result is in depth-first
+// order and excludes the error itself.
+func causes(err error) (result causeList) {
+	var wrapped []error
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		if e := x.Unwrap(); e != nil {
+			wrapped = []error{e}
+		}
+	case interface{ Unwrap() []error }:
+		wrapped = x.Unwrap()
+	}
+
+	for _, e := range wrapped {
+		if e != nil {
+			result = append(result, e.Error())
+			result = append(result, causes(e)...)
+		}
+	}
+	return
+}
+
+// Append the causes of the errors in the arguments of a printf-style method
+// to the fields, as a field whose key is 'causes'. The 'fields' parameter
+// won't be modified. It's only used by the ERROR and FATAL methods.
+func appendCauses(fields []Field, args []interface{}) []Field {
+	var all causeList
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			all = append(all, causes(err)...)
+		}
+	}
+
+	if len(all) == 0 {
+		return fields
+	}
+
+	result := make([]Field, 0, len(fields)+1)
+	return append(append(result, fields...), Field{"causes", all})
+}
+
+// Insert a field whose key is 'key.causes' after each field whose value is an
+// error wrapping other errors. The 'fields' parameter won't be modified.
+func expandCauses(fields []Field) []Field {
+	var result []Field
+	for i, f := range fields {
+		var cs causeList
+		if err, ok := f.Value.(error); ok {
+			cs = causes(err)
+		}
+
+		if len(cs) > 0 && result == nil {
+			result = make([]Field, i, len(fields)+1)
+			copy(result, fields[:i])
+		}
+
+		if result != nil {
+			result = append(result, f)
+			if len(cs) > 0 {
+				result = append(result, Field{f.Key + ".causes", cs})
+			}
+		}
+	}
+
+	if result == nil {
+		return fields
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+// stack_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/X-Plan/xgo/go-xassert"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Same as the error returned by errors.Join function.
+type joinError []error
+
+func (je joinError) Error() string {
+	strs := make([]string, 0, len(je))
+	for _, err := range je {
+		strs = append(strs, err.Error())
+	}
+	return strings.Join(strs, "\n")
+}
+
+func (je joinError) Unwrap() []error {
+	return je
+}
+
+func TestCauses(t *testing.T) {
+	base := errors.New("connection refused")
+	wrapped := fmt.Errorf("dial: %w", base)
+	joined := joinError{fmt.Errorf("query: %w", wrapped), errors.New("timeout")}
+
+	xassert.Equal(t, len(causes(base)), 0)
+	xassert.Equal(t, causes(wrapped), causeList{"connection refused"})
+	xassert.Equal(t, causes(joined), causeList{"query: dial: connection refused", "dial: connection refused", "connection refused", "timeout"})
+	xassert.Equal(t, causes(wrapped).String(), "connection refused")
+
+	fields := []Field{{"a", 1}, {"error", wrapped}, {"b", base}}
+	xassert.Equal(t, expandCauses(fields), []Field{{"a", 1}, {"error", wrapped}, {"error.causes", causeList{"connection refused"}}, {"b", base}})
+	xassert.Equal(t, len(fields), 3)
+	xassert.Equal(t, expandCauses(fields[:1]), fields[:1])
+
+	xassert.Equal(t, appendCauses(nil, []interface{}{1, base}), []Field(nil))
+	xassert.Equal(t, appendCauses(nil, []interface{}{wrapped}), []Field{{"causes", causeList{"connection refused"}}})
+}
+
+func TestStackLevel(t *testing.T) {
+	dir := "/tmp/xlog_stack_level"
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, StackLevel: DEBUG + 1})
+	xassert.IsNil(t, xl)
+	xassert.NotNil(t, err)
+
+	xl, err = New(&XConfig{Dir: dir, Level: INFO, StackLevel: ERROR, Format: FormatJSON})
+	xassert.IsNil(t, err)
+
+	wrapped := fmt.Errorf("dial: %w", errors.New("connection refused"))
+	xassert.IsNil(t, xl.Error("request failed (%s)", wrapped))
+	xassert.IsNil(t, xl.Errorw("retry", "error", wrapped))
+	// The records of lower priorities don't have the cause lists.
+	xassert.IsNil(t, xl.Info("request failed (%s)", wrapped))
+	xassert.IsNil(t, xl.InfoContext(context.Background(), "request failed (%s)", wrapped))
+	xassert.IsNil(t, xl.Warnw("retry", "error", wrapped))
+	xassert.IsNil(t, xl.Close())
+
+	lines := readLines(t, dir)
+	xassert.Equal(t, len(lines), 5)
+
+	var m map[string]interface{}
+	xassert.IsNil(t, json.Unmarshal([]byte(lines[0]), &m))
+	xassert.Equal(t, m["causes"], []interface{}{"connection refused"})
+	stack, _ := m["stack"].(string)
+	xassert.Match(t, stack, `^github.com/X-Plan/xgo/go-xlog.TestStackLevel\n\t.*/stack_test\.go:\d+\n`)
+	xassert.NotMatch(t, stack, `runtime\.goexit`)
+
+	m = nil
+	xassert.IsNil(t, json.Unmarshal([]byte(lines[1]), &m))
+	xassert.Equal(t, m["error"], "dial: connection refused")
+	xassert.Equal(t, m["error.causes"], []interface{}{"connection refused"})
+
+	for _, line := range lines[2:] {
+		m = nil
+		xassert.IsNil(t, json.Unmarshal([]byte(line), &m))
+		_, ok := m["causes"]
+		xassert.IsFalse(t, ok)
+		_, ok = m["error.causes"]
+		xassert.IsFalse(t, ok)
+		_, ok = m["stack"]
+		xassert.IsFalse(t, ok)
+	}
+}
+
+func TestStackOneLine(t *testing.T) {
+	dir := "/tmp/xlog_stack_one_line"
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, StackLevel: FATAL})
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, xl.Fatal("crash"))
+	xassert.IsNil(t, xl.Close())
+
+	lines := readLines(t, dir)
+	xassert.Equal(t, len(lines), 1)
+	xassert.Match(t, lines[0], `:crash stack="github.com/X-Plan/xgo/go-xlog.TestStackOneLine\\n\\t.*stack_test\.go:\d+\\n`)
+}
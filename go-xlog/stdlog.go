@@ -0,0 +1,68 @@
+// stdlog.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"fmt"
+	"log"
+)
+
+// stdWriter is the output of a standard logger, each line written by the
+// standard logger is converted to a record of the XLogger.
+type stdWriter struct {
+	xl    *XLogger
+	level int
+}
+
+// The frames between this method and the call site are: log.(*Logger).output
+// and the printing function (such as log.Printf or log.(*Logger).Printf).
+const stdCallDepth = 3
+
+func (sw stdWriter) Write(data []byte) (int, error) {
+	var pc uintptr
+	if sw.xl != nil && sw.xl.needPC(sw.level) {
+		pc = callerPC(stdCallDepth)
+	}
+
+	if _, err := sw.xl.outputPC(sw.level, pc, string(data), nil); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// StdLogger returns a standard logger which writes records to the XLogger at
+// the given level. The location of a record points at the call site of the
+// printing functions (such as 'Printf' method) of the standard logger. It's
+// useful for the libraries requiring a *log.Logger, such as http.Server.
+func (xl *XLogger) StdLogger(level int) (*log.Logger, error) {
+	if level < FATAL || level > DEBUG {
+		return nil, fmt.Errorf("level (%d) is invalid", level)
+	}
+	return log.New(stdWriter{xl, level}, "", 0), nil
+}
+
+// RedirectStdLog redirects the output of the standard log package to the
+// XLogger at the given level, the prefix and the flags of the standard log
+// package are cleared, because the XLogger has its own time and tag. The
+// returned function restores the original output, prefix and flags.
+func (xl *XLogger) RedirectStdLog(level int) (func(), error) {
+	if level < FATAL || level > DEBUG {
+		return nil, fmt.Errorf("level (%d) is invalid", level)
+	}
+
+	flags, prefix, w := log.Flags(), log.Prefix(), log.Writer()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(stdWriter{xl, level})
+
+	return func() {
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+		log.SetOutput(w)
+	}, nil
+}
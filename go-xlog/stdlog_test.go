@@ -0,0 +1,46 @@
+// stdlog_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestStdLog(t *testing.T) {
+	dir := "/tmp/xlog_stdlog"
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, Location: "always"})
+	xassert.IsNil(t, err)
+
+	_, err = xl.StdLogger(0)
+	xassert.NotNil(t, err)
+	_, err = xl.RedirectStdLog(DEBUG + 1)
+	xassert.NotNil(t, err)
+
+	logger, err := xl.StdLogger(ERROR)
+	xassert.IsNil(t, err)
+	logger.Printf("hello %s", "world")
+	logger.Println("foo")
+
+	restore, err := xl.RedirectStdLog(WARN)
+	xassert.IsNil(t, err)
+	log.Print("bar")
+	restore()
+	xassert.Equal(t, log.Flags(), log.LstdFlags)
+	xassert.IsNil(t, xl.Close())
+
+	lines := readLines(t, dir)
+	xassert.Equal(t, len(lines), 3)
+	xassert.Match(t, lines[0], `\[ERROR\]\[stdlog_test\.go\(\d+\) - .*TestStdLog\]:hello world$`)
+	xassert.Match(t, lines[1], `\[ERROR\]\[stdlog_test\.go\(\d+\) - .*TestStdLog\]:foo$`)
+	xassert.Match(t, lines[2], `\[WARN\]\[stdlog_test\.go\(\d+\) - .*TestStdLog\]:bar$`)
+}
@@ -0,0 +1,65 @@
+// sync_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xlog
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSync(t *testing.T) {
+	dir := "/tmp/xlog_sync"
+	defer os.RemoveAll(dir)
+
+	// The buffer won't be flushed periodically in this test.
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, FlushInterval: "1h"})
+	xassert.IsNil(t, err)
+
+	xassert.IsNil(t, xl.Info("first"))
+	xassert.IsNil(t, xl.Error("second"))
+	xassert.IsNil(t, xl.Sync())
+	checkLines(t, readLines(t, dir), "first", "second")
+
+	// FATAL records are synchronized automatically.
+	xassert.IsNil(t, xl.Fatal("third"))
+	checkLines(t, readLines(t, dir), "first", "second", "third")
+
+	xassert.IsNil(t, xl.Close())
+	xassert.Equal(t, xl.Sync(), ErrClosed)
+
+	var nilxl *XLogger
+	xassert.IsNil(t, nilxl.Sync())
+}
+
+func TestFlushInterval(t *testing.T) {
+	dir := "/tmp/xlog_flush_interval"
+	defer os.RemoveAll(dir)
+
+	xl, err := New(&XConfig{Dir: dir, Level: INFO, FlushInterval: "1 second"})
+	xassert.IsNil(t, xl)
+	xassert.NotNil(t, err)
+
+	xl, err = New(&XConfig{Dir: dir, Level: INFO, FlushInterval: "10ms"})
+	xassert.IsNil(t, err)
+	defer xl.Close()
+
+	for i := 0; i < 10; i++ {
+		xassert.IsNil(t, xl.Info("hello"))
+	}
+
+	var lines []string
+	for i := 0; i < 100 && len(lines) < 10; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if fiq, err := createFileInfoQueue(dir); err == nil && fiq.Len() > 0 {
+			lines = readLines(t, dir)
+		}
+	}
+	checkLines(t, lines, "hello", "hello", "hello", "hello", "hello", "hello", "hello", "hello", "hello", "hello")
+}
@@ -3,36 +3,36 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2017-02-07
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2017-06-29
+// Last Change: 2026-10-17
 
 package xlog
 
 type FatalWrapper struct{ *XLogger }
 
 func (fw FatalWrapper) Write(data []byte) (int, error) {
-	return fw.XLogger.output(FATAL, string(data))
+	return fw.XLogger.output(FATAL, string(data), nil)
 }
 
 type ErrorWrapper struct{ *XLogger }
 
 func (ew ErrorWrapper) Write(data []byte) (int, error) {
-	return ew.XLogger.output(ERROR, string(data))
+	return ew.XLogger.output(ERROR, string(data), nil)
 }
 
 type WarnWrapper struct{ *XLogger }
 
 func (ww WarnWrapper) Write(data []byte) (int, error) {
-	return ww.XLogger.output(WARN, string(data))
+	return ww.XLogger.output(WARN, string(data), nil)
 }
 
 type InfoWrapper struct{ *XLogger }
 
 func (iw InfoWrapper) Write(data []byte) (int, error) {
-	return iw.XLogger.output(INFO, string(data))
+	return iw.XLogger.output(INFO, string(data), nil)
 }
 
 type DebugWrapper struct{ *XLogger }
 
 func (dw DebugWrapper) Write(data []byte) (int, error) {
-	return dw.XLogger.output(DEBUG, string(data))
+	return dw.XLogger.output(DEBUG, string(data), nil)
 }
@@ -3,11 +3,12 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2018-01-24
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2018-01-29
+// Last Change: 2026-10-17
 package xlog
 
 import (
 	"fmt"
+	"io"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -36,13 +37,140 @@ type XConfig struct {
 	// limit, it will be deleted.
 	MaxAge string `json:"max_age" yaml:"max_age"`
 
+	// Rotate log files on wall-clock boundaries, the valid values are 'none',
+	// 'hourly' and 'daily'. If it's 'hourly' or 'daily', each log file only
+	// holds the records of one period, and it's combined with 'MaxSize' field,
+	// so a period may have several log files. If it's empty, 'none' will be
+	// used by default.
+	Rotate string `json:"rotate" yaml:"rotate"`
+
+	// Whether compressing the rotated log files by gzip in the background.
+	// The compressed files have the '.gz' suffix, and they are still limited
+	// by 'MaxBackups' and 'MaxAge' fields.
+	Compress bool `json:"compress" yaml:"compress"`
+
+	// If it's set, the records are written to the file with this fixed name
+	// in the directory, instead of the rotated log files. In this mode, the
+	// built-in rotation ('MaxSize', 'MaxBackups', 'MaxAge', 'Rotate' and
+	// 'Compress' fields) is disabled, so the file can be rotated by an external
+	// tool (such as logrotate), then reopened by 'Reopen' method of XLogger.
+	Filename string `json:"filename" yaml:"filename"`
+
+	// Whether calling 'Reopen' method of XLogger when the process receives
+	// SIGHUP signal. It's useful with 'Filename' field.
+	ReopenOnSIGHUP bool `json:"reopen_on_sighup" yaml:"reopen_on_sighup"`
+
 	// Log tag. If not set, the process name will be used by default.
 	Tag string `json:"tag" yaml:"tag"`
 
 	// Log level. Only the priority of operation is higher than this field,
 	// messages can be written to a log file, otherwise written to standard
-	// error output. Call 'Write' function will ignore this field.
+	// error output. Call 'Write' function will ignore this field. It can be
+	// changed at runtime by 'SetLevel' method of XLogger.
+	Level int `json:"level" yaml:"level"`
+
+	// The format of each record, the valid values are 'text', 'json' and
+	// 'logfmt'. If it's empty, the 'text' format will be used by default.
+	Format string `json:"format" yaml:"format"`
+
+	// The layout (see time.Format) of the timestamp in each record. If it's
+	// empty, the default layout of the format will be used.
+	TimeFormat string `json:"time_format" yaml:"time_format"`
+
+	// Whether including the location of an event happening, the valid values
+	// are 'auto', 'always' and 'never'. The 'auto' policy (default) includes
+	// the location except INFO level. It's passed to the builtin encoder as
+	// the 'Location' field of EncoderConfig.
+	Location string `json:"location" yaml:"location"`
+
+	// The records whose priority is higher than or equal to this level carry
+	// the stack trace of the goroutine as a field whose key is 'stack', for
+	// example, ERROR represents ERROR and FATAL records. If it's zero, no record
+	// carries the stack trace.
+	StackLevel int `json:"stack_level" yaml:"stack_level"`
+
+	// The customized encoder. If it's set, 'Format', 'TimeFormat' and
+	// 'Location' fields will be ignored.
+	Encoder Encoder `json:"-" yaml:"-"`
+
+	// The capacity of the buffer channel between the invokers and the flush
+	// routine. If it's zero, 128 will be used by default.
+	BufferSize int `json:"buffer_size" yaml:"buffer_size"`
+
+	// The policy when the buffer channel is full, the valid values are:
+	//  'block'       - Block the invoker until the buffer channel has space (default).
+	//  'drop_newest' - Drop the record being written, ErrDropped will be returned.
+	//  'drop_oldest' - Drop the oldest record in the buffer channel.
+	//  'sample'      - Block one of every 'OverflowSample' records, and drop the others.
+	// The FATAL records are never dropped. The number of dropped records can
+	// be got by 'Stats' method of XLogger.
+	Overflow string `json:"overflow" yaml:"overflow"`
+
+	// It's only used by the 'sample' policy. If it's zero, 10 will be used by default.
+	OverflowSample int `json:"overflow_sample" yaml:"overflow_sample"`
+
+	// The records are written to a buffer of each log file at first. If this
+	// field is empty, the buffer is flushed when there is no record waiting
+	// to be written, otherwise it's flushed periodically (for example, '1s').
+	// 'Sync' method of XLogger can be used to flush the buffer forcibly.
+	FlushInterval string `json:"flush_interval" yaml:"flush_interval"`
+
+	// Limit the number of records written by each call site (the location
+	// invoking the methods of XLogger). In each 'SampleInterval' (for example,
+	// '1s'), the first 'SampleInitial' records of a call site are written, then
+	// only one of every 'SampleThereafter' records is written, zero represents
+	// dropping all of them. When an interval ends, a summary record containing
+	// the number of suppressed records is written for each call site. FATAL
+	// records and the 'Write' method are never sampled. The sampling is disabled
+	// if 'SampleInterval' field is empty.
+	SampleInitial    int    `json:"sample_initial" yaml:"sample_initial"`
+	SampleThereafter int    `json:"sample_thereafter" yaml:"sample_thereafter"`
+	SampleInterval   string `json:"sample_interval" yaml:"sample_interval"`
+
+	// If it's set, the errors occurred when writing records to sinks are passed
+	// to this callback (it's called by the flush routine, so it shouldn't block),
+	// otherwise they are returned to the invoker which writes the next record.
+	OnError func(error) `json:"-" yaml:"-"`
+
+	// Additional destinations of records. The directory specified by 'Dir'
+	// field is always the first destination, and its level is 'Level' field.
+	Sinks []SinkConfig `json:"sinks" yaml:"sinks"`
+}
+
+// This configure type is used to create an additional destination of records.
+type SinkConfig struct {
+	// The type of the sink, the valid values are as follows:
+	//  'dir'    - A directory storing rotated log files, like the main directory.
+	//  'stdout' - The standard output.
+	//  'stderr' - The standard error output.
+	//  'syslog' - A syslog-style socket.
+	//  'writer' - An arbitrary io.Writer, it can't be imported.
+	Type string `json:"type" yaml:"type"`
+
+	// Only the priority of a record is higher than or equal to this field,
+	// it can be written to the sink. If it's zero, the sink follows the
+	// level of the module writing a record (see 'SetLevel' method of XLogger).
 	Level int `json:"level" yaml:"level"`
+
+	// The following seven fields are only used by the 'dir' type, and they
+	// have the same meaning as the corresponding fields of XConfig. Two sinks
+	// (include the main directory) can't share the same directory.
+	Dir        string `json:"dir" yaml:"dir"`
+	MaxSize    int64  `json:"max_size" yaml:"max_size"`
+	MaxBackups int64  `json:"max_backups" yaml:"max_backups"`
+	MaxAge     string `json:"max_age" yaml:"max_age"`
+	Rotate     string `json:"rotate" yaml:"rotate"`
+	Compress   bool   `json:"compress" yaml:"compress"`
+	Filename   string `json:"filename" yaml:"filename"`
+
+	// The following two fields are only used by the 'syslog' type, their
+	// meaning is same as the parameters of net.Dial function. If both are
+	// empty, the local syslog daemon will be used.
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+
+	// It's only used by the 'writer' type.
+	Writer io.Writer `json:"-" yaml:"-"`
 }
 
 // Import a readable format data to the XConfig instance. Even through this function
@@ -61,14 +189,8 @@ func (xcfg *XConfig) Import(data map[string]interface{}) error {
 		}
 	}
 
-	maxBackups := reflect.ValueOf(data["max_backups"])
-	switch maxBackups.Kind() {
-	case reflect.Float32, reflect.Float64:
-		xcfg.MaxBackups = int64(maxBackups.Float())
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		xcfg.MaxBackups = maxBackups.Int()
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		xcfg.MaxBackups = int64(maxBackups.Uint())
+	if number, ok := parseNumber(data["max_backups"]); ok {
+		xcfg.MaxBackups = number
 	}
 
 	if str, ok := (data["max_age"]).(string); ok {
@@ -77,6 +199,25 @@ func (xcfg *XConfig) Import(data map[string]interface{}) error {
 		}
 	}
 
+	if str, ok := (data["rotate"]).(string); ok {
+		if _, err = parseRotate(str); err != nil {
+			return fmt.Errorf("'rotate' %s", err)
+		}
+		xcfg.Rotate = str
+	}
+
+	if b, ok := (data["compress"]).(bool); ok {
+		xcfg.Compress = b
+	}
+
+	if str, ok := (data["filename"]).(string); ok {
+		xcfg.Filename = str
+	}
+
+	if b, ok := (data["reopen_on_sighup"]).(bool); ok {
+		xcfg.ReopenOnSIGHUP = b
+	}
+
 	if str, ok := (data["tag"]).(string); ok {
 		xcfg.Tag = str
 	}
@@ -87,9 +228,159 @@ func (xcfg *XConfig) Import(data map[string]interface{}) error {
 		}
 	}
 
+	if str, ok := (data["format"]).(string); ok {
+		if _, err = NewEncoder(str, EncoderConfig{}); err != nil {
+			return fmt.Errorf("'format' %s", err)
+		}
+		xcfg.Format = str
+	}
+
+	if str, ok := (data["time_format"]).(string); ok {
+		xcfg.TimeFormat = str
+	}
+
+	if str, ok := (data["location"]).(string); ok {
+		if err = checkLocation(str); err != nil {
+			return fmt.Errorf("'location' %s", err)
+		}
+		xcfg.Location = str
+	}
+
+	if str, ok := (data["stack_level"]).(string); ok {
+		if xcfg.StackLevel, err = parseLevel(str); err != nil {
+			return fmt.Errorf("'stack_level' %s", err)
+		}
+	}
+
+	if number, ok := parseNumber(data["buffer_size"]); ok {
+		xcfg.BufferSize = int(number)
+	}
+
+	if str, ok := (data["overflow"]).(string); ok {
+		if _, err = parseOverflow(str); err != nil {
+			return fmt.Errorf("'overflow' %s", err)
+		}
+		xcfg.Overflow = str
+	}
+
+	if number, ok := parseNumber(data["overflow_sample"]); ok {
+		xcfg.OverflowSample = int(number)
+	}
+
+	if str, ok := (data["flush_interval"]).(string); ok {
+		if _, err = time.ParseDuration(str); err != nil {
+			return fmt.Errorf("'flush_interval' %s", err)
+		}
+		xcfg.FlushInterval = str
+	}
+
+	if number, ok := parseNumber(data["sample_initial"]); ok {
+		xcfg.SampleInitial = int(number)
+	}
+
+	if number, ok := parseNumber(data["sample_thereafter"]); ok {
+		xcfg.SampleThereafter = int(number)
+	}
+
+	if str, ok := (data["sample_interval"]).(string); ok {
+		if _, err = time.ParseDuration(str); err != nil {
+			return fmt.Errorf("'sample_interval' %s", err)
+		}
+		xcfg.SampleInterval = str
+	}
+
+	if sinks, ok := (data["sinks"]).([]interface{}); ok {
+		xcfg.Sinks = make([]SinkConfig, len(sinks))
+		for i, sink := range sinks {
+			m, ok := sink.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("'sinks' invalid element (%v)", sink)
+			}
+			if err = xcfg.Sinks[i].Import(m); err != nil {
+				return fmt.Errorf("'sinks' %s", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Import a readable format data to the SinkConfig instance, the format of
+// fields is same as XConfig.
+func (scfg *SinkConfig) Import(data map[string]interface{}) error {
+	var err error
+
+	if str, ok := (data["type"]).(string); ok {
+		scfg.Type = str
+	}
+
+	if str, ok := (data["level"]).(string); ok {
+		if scfg.Level, err = parseLevel(str); err != nil {
+			return fmt.Errorf("'level' %s", err)
+		}
+	}
+
+	if str, ok := (data["dir"]).(string); ok {
+		scfg.Dir = str
+	}
+
+	if str, ok := (data["max_size"]).(string); ok {
+		if scfg.MaxSize, err = parseMaxSize(str); err != nil {
+			return fmt.Errorf("'max_size' %s", err)
+		}
+	}
+
+	if number, ok := parseNumber(data["max_backups"]); ok {
+		scfg.MaxBackups = number
+	}
+
+	if str, ok := (data["max_age"]).(string); ok {
+		if scfg.MaxAge, err = parseMaxAge(str); err != nil {
+			return fmt.Errorf("'max_age' %s", err)
+		}
+	}
+
+	if str, ok := (data["rotate"]).(string); ok {
+		if _, err = parseRotate(str); err != nil {
+			return fmt.Errorf("'rotate' %s", err)
+		}
+		scfg.Rotate = str
+	}
+
+	if b, ok := (data["compress"]).(bool); ok {
+		scfg.Compress = b
+	}
+
+	if str, ok := (data["filename"]).(string); ok {
+		scfg.Filename = str
+	}
+
+	if str, ok := (data["network"]).(string); ok {
+		scfg.Network = str
+	}
+
+	if str, ok := (data["address"]).(string); ok {
+		scfg.Address = str
+	}
+
 	return nil
 }
 
+// Convert a number of any type to int64, the second result is false if
+// the value isn't a number.
+func parseNumber(v interface{}) (int64, bool) {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return int64(value.Float()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint()), true
+	}
+	return 0, false
+}
+
 // The readable format of 'max_size' field: NUMBER [N space] {kb|KB|mb|MB|gb|GB}
 // You can add some spaces at the head, but I don't recommend it.
 var reMaxSize = regexp.MustCompile(`^\s*(\d+)\s*(b|B|kb|KB|mb|MB|gb|GB)$`)
@@ -226,13 +517,111 @@ func (xcfg *XConfig) Export(data map[string]interface{}) error {
 			data["max_age"] = readableMaxAge(min)
 		}
 	}
+	if len(xcfg.Rotate) != 0 {
+		data["rotate"] = xcfg.Rotate
+	}
+	if xcfg.Compress {
+		data["compress"] = true
+	}
+	if len(xcfg.Filename) != 0 {
+		data["filename"] = xcfg.Filename
+	}
+	if xcfg.ReopenOnSIGHUP {
+		data["reopen_on_sighup"] = true
+	}
 	if len(xcfg.Tag) != 0 {
 		data["tag"] = xcfg.Tag
 	}
 	if xcfg.Level > 0 && xcfg.Level < len(levelReadable) {
 		data["level"] = levelReadable[xcfg.Level]
 	}
+	if len(xcfg.Format) != 0 {
+		data["format"] = xcfg.Format
+	}
+	if len(xcfg.TimeFormat) != 0 {
+		data["time_format"] = xcfg.TimeFormat
+	}
+	if len(xcfg.Location) != 0 {
+		data["location"] = xcfg.Location
+	}
+	if xcfg.StackLevel > 0 && xcfg.StackLevel < len(levelReadable) {
+		data["stack_level"] = levelReadable[xcfg.StackLevel]
+	}
+	if xcfg.BufferSize > 0 {
+		data["buffer_size"] = xcfg.BufferSize
+	}
+	if len(xcfg.Overflow) != 0 {
+		data["overflow"] = xcfg.Overflow
+	}
+	if xcfg.OverflowSample > 0 {
+		data["overflow_sample"] = xcfg.OverflowSample
+	}
+	if len(xcfg.FlushInterval) != 0 {
+		data["flush_interval"] = xcfg.FlushInterval
+	}
+	if xcfg.SampleInitial > 0 {
+		data["sample_initial"] = xcfg.SampleInitial
+	}
+	if xcfg.SampleThereafter > 0 {
+		data["sample_thereafter"] = xcfg.SampleThereafter
+	}
+	if len(xcfg.SampleInterval) != 0 {
+		data["sample_interval"] = xcfg.SampleInterval
+	}
+	if len(xcfg.Sinks) != 0 {
+		sinks := make([]interface{}, len(xcfg.Sinks))
+		for i := range xcfg.Sinks {
+			m := make(map[string]interface{})
+			if err := xcfg.Sinks[i].Export(m); err != nil {
+				return err
+			}
+			sinks[i] = m
+		}
+		data["sinks"] = sinks
+	}
+
+	return nil
+}
 
+// Export a SinkConfig instance to a readable format data.
+func (scfg *SinkConfig) Export(data map[string]interface{}) error {
+	if len(scfg.Type) != 0 {
+		data["type"] = scfg.Type
+	}
+	if scfg.Level > 0 && scfg.Level < len(levelReadable) {
+		data["level"] = levelReadable[scfg.Level]
+	}
+	if len(scfg.Dir) != 0 {
+		data["dir"] = scfg.Dir
+	}
+	if scfg.MaxSize > 0 {
+		data["max_size"] = readableMaxSize(int(scfg.MaxSize))
+	}
+	if scfg.MaxBackups > 0 {
+		data["max_backups"] = scfg.MaxBackups
+	}
+	if len(scfg.MaxAge) != 0 {
+		if maxAge, err := time.ParseDuration(scfg.MaxAge); err != nil {
+			return fmt.Errorf("invalid 'MaxAge' (%s)", err)
+		} else if min := int(maxAge / time.Minute); min > 0 {
+			data["max_age"] = readableMaxAge(min)
+		}
+	}
+	if len(scfg.Rotate) != 0 {
+		data["rotate"] = scfg.Rotate
+	}
+	if scfg.Compress {
+		data["compress"] = true
+	}
+	if len(scfg.Filename) != 0 {
+		data["filename"] = scfg.Filename
+	}
+	if len(scfg.Network) != 0 {
+		data["network"] = scfg.Network
+	}
+	if len(scfg.Address) != 0 {
+		data["address"] = scfg.Address
+	}
 	return nil
 }
 
@@ -3,7 +3,7 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2018-01-26
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2018-01-30
+// Last Change: 2026-10-17
 package xlog
 
 import (
@@ -146,6 +146,7 @@ func TestImportAndExport(t *testing.T) {
 			"max_size":    "2 GB",
 			"max_backups": 50,
 			"max_age":     "6 month",
+			"rotate":      "hourly",
 			"tag":         "test 1",
 			"level":       "info",
 		}, true},
@@ -164,6 +165,15 @@ func TestImportAndExport(t *testing.T) {
 		{map[string]interface{}{"max_age": "5 month"}, true},
 		{map[string]interface{}{"tag": "hello"}, true},
 		{map[string]interface{}{"level": "fatal"}, true},
+		{map[string]interface{}{"format": "json", "time_format": "2006-01-02", "location": "always", "stack_level": "error"}, true},
+		{map[string]interface{}{"buffer_size": 1024, "overflow": "sample", "overflow_sample": float64(100), "flush_interval": "1s"}, true},
+		{map[string]interface{}{"dir": "/var/log/app", "filename": "app.log", "reopen_on_sighup": true}, true},
+		{map[string]interface{}{"sample_initial": 100, "sample_thereafter": float64(10), "sample_interval": "1s"}, true},
+		{map[string]interface{}{"sinks": []interface{}{
+			map[string]interface{}{"type": "dir", "dir": "/tmp/log/error", "level": "error", "max_size": "10 MB", "max_backups": 10, "max_age": "1 week", "rotate": "daily"},
+			map[string]interface{}{"type": "dir", "dir": "/tmp/log/access", "filename": "access.log"},
+			map[string]interface{}{"type": "syslog", "network": "udp", "address": "127.0.0.1:514"},
+		}}, true},
 
 		{map[string]interface{}{
 			"dir":         "/tmp/log",
@@ -176,6 +186,15 @@ func TestImportAndExport(t *testing.T) {
 		{map[string]interface{}{"max_size": "a MB"}, false},
 		{map[string]interface{}{"max_age": "10 days"}, false},
 		{map[string]interface{}{"level": "nothing"}, false},
+		{map[string]interface{}{"stack_level": "panic"}, false},
+		{map[string]interface{}{"format": "xml"}, false},
+		{map[string]interface{}{"rotate": "weekly"}, false},
+		{map[string]interface{}{"overflow": "drop"}, false},
+		{map[string]interface{}{"flush_interval": "1 second"}, false},
+		{map[string]interface{}{"sample_interval": "1 second"}, false},
+		{map[string]interface{}{"location": "sometimes"}, false},
+		{map[string]interface{}{"sinks": []interface{}{"stderr"}}, false},
+		{map[string]interface{}{"sinks": []interface{}{map[string]interface{}{"level": "none"}}}, false},
 	}
 
 	for _, element := range elements {
@@ -3,22 +3,23 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2016-10-26
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2018-08-24
+// Last Change: 2026-10-17
 
 // go-xlog implement a concurrently safe rotate-log.
 package xlog
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -49,45 +50,146 @@ var dirMtx = &sync.Mutex{}
 // Throw this error when operating on a closed log.
 var ErrClosed = errors.New("XLogger has been closed")
 
-// The format of log:
-// [yyyy-mm-dd hh:mm:ss][tag][level][location]: message
+// The default format of log (see TextEncoder):
+// [yyyy-mm-dd hh:mm:ss][tag][level][location]: message [key=value ...]
 // '[yyyy-mm-dd hh:mm:ss]' - The timestamp writing record
 // '[tag]' - User defined tag
 // '[level]' - The priority of record
 // '[location]' - Location of an event happening, Info() function doesn't print the location information.
 // 'message' - User defined data
+// 'key=value' - Fields attached by the structured API (see 'With' method)
 type XLogger struct {
-	dir   string
-	ms    int64
-	mb    int64
-	ma    time.Duration
-	tag   string
-	level int
+	// All XLoggers derived from the same root (by 'With' method) share
+	// one core, which means they write to the same directory through
+	// the same buffer channel.
+	*core
+
+	tag    string
+	fields []Field
+
+	// The name of the module which the XLogger belongs to, the level of a
+	// module can be overridden (see 'Module' method). Empty string represents
+	// the root module.
+	module string
+}
+
+// The shared part of XLogger, it owns the sinks and the flush routine.
+type core struct {
+	// The counters of Stats, they must be accessed by atomic operations.
+	// They are placed at the beginning of the struct to make sure they're
+	// 64-bit aligned on 32-bit platforms.
+	dropped     uint64
+	writeErrors uint64
+	overflows   uint64
+	sampled     uint64
+
+	// The main directory, it's also the first sink.
+	dir string
+
+	// The level of the root module, it can be changed at runtime, so it
+	// must be accessed by atomic operations.
+	level int32
+
+	// The overridden levels of modules, the type of the value is
+	// map[string]int. The map is replaced entirely (copy-on-write) when
+	// it's changed, so reading it doesn't need a lock. 'mmtx' is only
+	// used to serialize modifications.
+	modules atomic.Value
+	mmtx    sync.Mutex
+
+	// All destinations of records, the flush routine writes a record to
+	// the sinks whose level is lower than or equal to the record's.
+	sinks []leveledSink
+
+	// Encode a record to a line, it also decides which records include the
+	// location (see Encoder).
+	encoder Encoder
+
+	// The records whose priority is higher than or equal to this level
+	// carry the stack trace, zero represents no stack trace.
+	stackLevel int
 
 	// Because the XLogger instance should be used safely in concurrency environment,
 	// I use a channel to impelment this feature, sequence the data.
-	bc chan []byte
+	bc chan record
+
+	// The policy when the buffer channel is full, see 'Overflow' field of XConfig.
+	overflow       int
+	overflowSample uint64
+
+	// The flush routine flushes the buffered data of sinks when the buffer
+	// channel is empty if it's zero, otherwise flushes them periodically.
+	flushInterval time.Duration
+
+	// Limit the number of records written by each call site, it's nil if
+	// the sampling is disabled.
+	sampler *sampler
+
+	// Stop the routine reopening files on SIGHUP signal and wait for it to
+	// exit, they are nil if the routine isn't started.
+	hupStop chan int
+	hupExit chan int
 
 	// Notify to the main routine that the flush routine has exited.
 	exitChan chan int
 
-	// Get the error information of the flush routine.
+	// Get the error information of the flush routine. If 'onError' isn't
+	// nil, the errors are passed to it instead of 'errorChan'.
 	errorChan chan error
+	onError   func(error)
 
-	f    *file
 	half int32
 }
 
+// The element of the buffer channel.
+type record struct {
+	// The priority of the record, zero represents the record comes from
+	// the 'Write' method which ignores the level.
+	level int
+
+	// The level of the module writing this record, it's used by the sinks
+	// which haven't their own levels.
+	threshold int
+
+	// The time of the record, it decides which period the record belongs
+	// to when rotating log files by time.
+	t time.Time
+
+	b []byte
+
+	// If it isn't nil, the record is a barrier (see 'Sync' method) instead
+	// of data. The flush routine flushes all sinks to the disk when it meets
+	// a barrier, then sends the result to this channel.
+	done chan error
+
+	// If it's true, the barrier reopens the sinks instead of flushing
+	// them (see 'Reopen' method).
+	reopen bool
+}
+
+// Whether a record will be accepted by at least one sink, otherwise it
+// will be written to standard output directly.
+func (c *core) accept(level, threshold int) bool {
+	for _, s := range c.sinks {
+		if level <= s.threshold(threshold) {
+			return true
+		}
+	}
+	return false
+}
+
 func New(xcfg *XConfig) (xl *XLogger, err error) {
 
 	defer func() {
 		if err != nil {
-			unbindDir(xl.dir)
+			for _, s := range xl.sinks {
+				s.close()
+			}
 			xl = nil
 		}
 	}()
 
-	xl = &XLogger{}
+	xl = &XLogger{core: &core{}}
 
 	if xcfg == nil {
 		err = fmt.Errorf("XConfig is nil")
@@ -101,64 +203,122 @@ func New(xcfg *XConfig) (xl *XLogger, err error) {
 		xl.dir = "./log"
 	}
 
-	if err = bindDir(xl.dir); err != nil {
-		return
+	if xcfg.Tag != "" {
+		xl.tag = xcfg.Tag
+	} else {
+		xl.tag = filepath.Base(os.Args[0])
 	}
 
-	if err = os.MkdirAll(xl.dir, 0777); err != nil {
+	if xcfg.Level >= FATAL && xcfg.Level <= DEBUG {
+		xl.core.level = int32(xcfg.Level)
+	} else {
+		err = fmt.Errorf("Level is invalid")
 		return
 	}
-	// Check whether user can write to this directory.
-	if err = isWritable(xl.dir); err != nil {
+
+	var ms sink
+	if ms, err = newFileOrDirSink(xl.dir, xcfg.Filename, xcfg.MaxSize, xcfg.MaxBackups, xcfg.MaxAge, xcfg.Rotate, xcfg.Compress); err != nil {
 		return
 	}
+	xl.sinks = append(xl.sinks, leveledSink{ms, 0})
 
-	if xcfg.MaxSize < 0 {
-		err = fmt.Errorf("MaxSize is invalid")
-		return
+	for i := range xcfg.Sinks {
+		var ls leveledSink
+		if ls, err = newSink(&xcfg.Sinks[i]); err != nil {
+			err = fmt.Errorf("Sinks[%d] is invalid (%s)", i, err)
+			return
+		}
+		xl.sinks = append(xl.sinks, ls)
 	}
-	xl.ms = xcfg.MaxSize
 
-	if xcfg.MaxBackups < 0 {
-		err = fmt.Errorf("MaxBackups is invalid")
+	if xcfg.Encoder != nil {
+		xl.encoder = xcfg.Encoder
+	} else if xl.encoder, err = NewEncoder(xcfg.Format, EncoderConfig{TimeFormat: xcfg.TimeFormat, Location: xcfg.Location}); err != nil {
 		return
 	}
-	xl.mb = xcfg.MaxBackups
 
-	if xcfg.MaxAge != "" {
-		xl.ma, err = time.ParseDuration(xcfg.MaxAge)
-		if err != nil {
+	if xcfg.StackLevel != 0 {
+		if xcfg.StackLevel < FATAL || xcfg.StackLevel > DEBUG {
+			err = fmt.Errorf("StackLevel is invalid")
 			return
 		}
-	} else {
-		xl.ma = time.Duration(0)
+		xl.stackLevel = xcfg.StackLevel
 	}
 
-	if xcfg.Tag != "" {
-		xl.tag = xcfg.Tag
+	if xcfg.BufferSize < 0 {
+		err = fmt.Errorf("BufferSize is invalid")
+		return
+	} else if xcfg.BufferSize > 0 {
+		xl.bc = make(chan record, xcfg.BufferSize)
 	} else {
-		xl.tag = filepath.Base(os.Args[0])
+		xl.bc = make(chan record, 128)
 	}
 
-	if xcfg.Level >= FATAL && xcfg.Level <= DEBUG {
-		xl.level = xcfg.Level
-	} else {
-		err = fmt.Errorf("Level is invalid")
+	if xcfg.Overflow != "" {
+		if xl.overflow, err = parseOverflow(xcfg.Overflow); err != nil {
+			return
+		}
+	}
+
+	if xcfg.OverflowSample < 0 {
+		err = fmt.Errorf("OverflowSample is invalid")
 		return
+	} else if xcfg.OverflowSample > 0 {
+		xl.overflowSample = uint64(xcfg.OverflowSample)
+	} else {
+		xl.overflowSample = 10
+	}
+
+	if xcfg.FlushInterval != "" {
+		if xl.flushInterval, err = time.ParseDuration(xcfg.FlushInterval); err != nil {
+			return
+		}
+	}
+
+	if xcfg.SampleInterval != "" {
+		var interval time.Duration
+		if interval, err = time.ParseDuration(xcfg.SampleInterval); err != nil {
+			return
+		} else if interval <= 0 {
+			err = fmt.Errorf("SampleInterval is invalid")
+			return
+		}
+
+		if xcfg.SampleInitial < 0 {
+			err = fmt.Errorf("SampleInitial is invalid")
+			return
+		}
+
+		if xcfg.SampleThereafter < 0 {
+			err = fmt.Errorf("SampleThereafter is invalid")
+			return
+		}
+		xl.sampler = newSampler(xcfg.SampleInitial, xcfg.SampleThereafter, interval)
 	}
 
-	xl.bc = make(chan []byte, 128)
+	xl.onError = xcfg.OnError
+	xl.modules.Store(map[string]int{})
 	xl.exitChan = make(chan int)
 	xl.errorChan = make(chan error, 8)
 
 	// 'flush' routine is used to flush data to disk.
 	go xl.flush()
 
+	if xl.sampler != nil {
+		go xl.sampler.run()
+	}
+
+	if xcfg.ReopenOnSIGHUP {
+		xl.watchSIGHUP()
+	}
+
 	return xl, nil
 }
 
 // Write data to the XLogger instance. You don't need call
-// this method in most cases.
+// this method in most cases. The data will be written to the directory
+// sinks (including the main directory) regardless of their levels, other
+// sinks never receive it.
 func (xl *XLogger) Write(data []byte) (n int, err error) {
 	if xl == nil {
 		return 0, nil
@@ -167,6 +327,12 @@ func (xl *XLogger) Write(data []byte) (n int, err error) {
 	b := make([]byte, len(data))
 	copy(b, data)
 
+	return xl.enqueue(record{0, 0, time.Now(), b, nil, false})
+}
+
+// Push a record to the buffer channel, the behaviour depends on the
+// overflow policy when the buffer channel is full.
+func (xl *XLogger) enqueue(r record) (n int, err error) {
 	// Write data to closed channel will throw a panic,
 	// capture this panic and return a readable error to
 	// the invoker.
@@ -176,42 +342,54 @@ func (xl *XLogger) Write(data []byte) (n int, err error) {
 		}
 	}()
 
+	if xl.overflow != overflowBlock {
+		select {
+		case xl.bc <- r:
+			return len(r.b), nil
+		default:
+			if block, err := xl.overflowed(r); err != nil {
+				return 0, err
+			} else if !block {
+				return len(r.b), nil
+			}
+		}
+	}
+
 	select {
-	case xl.bc <- b:
-		n, err = len(b), nil
+	case xl.bc <- r:
+		n, err = len(r.b), nil
 	case err = <-xl.errorChan:
 		if err == nil {
-			n, err = 0, ErrClosed
-		} else {
-			n, err = 0, err
+			err = ErrClosed
 		}
+		n = 0
 	}
 
 	return
 }
 
 func (xl *XLogger) Fatal(format string, args ...interface{}) error {
-	_, err := xl.output(FATAL, xl.sprintf(format, args...))
+	_, err := xl.output(FATAL, xl.sprintf(format, args...), appendCauses(nil, args))
 	return err
 }
 
 func (xl *XLogger) Error(format string, args ...interface{}) error {
-	_, err := xl.output(ERROR, xl.sprintf(format, args...))
+	_, err := xl.output(ERROR, xl.sprintf(format, args...), appendCauses(nil, args))
 	return err
 }
 
 func (xl *XLogger) Warn(format string, args ...interface{}) error {
-	_, err := xl.output(WARN, xl.sprintf(format, args...))
+	_, err := xl.output(WARN, xl.sprintf(format, args...), nil)
 	return err
 }
 
 func (xl *XLogger) Info(format string, args ...interface{}) error {
-	_, err := xl.output(INFO, xl.sprintf(format, args...))
+	_, err := xl.output(INFO, xl.sprintf(format, args...), nil)
 	return err
 }
 
 func (xl *XLogger) Debug(format string, args ...interface{}) error {
-	_, err := xl.output(DEBUG, xl.sprintf(format, args...))
+	_, err := xl.output(DEBUG, xl.sprintf(format, args...), nil)
 	return err
 }
 
@@ -227,7 +405,9 @@ func (xl *XLogger) sprintf(format string, args ...interface{}) string {
 
 // Close the XLogger instance. Operating on a closed XLogger instance
 // will return the 'ErrClosed' error. The XLogger instance will be also
-// closed if meeting an exception when flush data to disk.
+// closed if meeting an exception when flush data to disk. All XLoggers
+// derived from the same root share one core, so closing any of them
+// closes all of them.
 func (xl *XLogger) Close() (err error) {
 	if xl == nil {
 		return nil
@@ -241,54 +421,96 @@ func (xl *XLogger) Close() (err error) {
 		}
 	}()
 
+	if xl.hupStop != nil {
+		xl.unwatchSIGHUP()
+	}
+
+	// The last summaries of the sampler should be written before closing
+	// the buffer channel.
+	if xl.sampler != nil {
+		xl.sampler.close()
+	}
+
 	close(xl.bc)
 
 	// Waitting for 'flush' routine exited.  The purpose of
 	// this operation is avoiding the main routine exited before
-	// 'flush' routine, which may cause partial data lost.
+	// 'flush' routine, which may cause partial data lost. The
+	// directories are unbound by the 'flush' routine when it
+	// closes the sinks.
 	<-xl.exitChan
 
-	// Unbind the directory.
-	unbindDir(xl.dir)
-
 	return
 }
 
 func (xl *XLogger) flush() {
 	var (
-		err error
-		b   []byte
+		ticker *time.Ticker
+		tc     <-chan time.Time // It's nil when the ticker isn't used, so it blocks forever.
+		dirty  bool             // Whether some sinks have unflushed data.
 	)
 
+	if xl.flushInterval > 0 {
+		ticker = time.NewTicker(xl.flushInterval)
+		defer ticker.Stop()
+		tc = ticker.C
+	}
+
 	// This loop will be ended when the main routine closes 'bc' channel.
-	for b = range xl.bc {
-		if err = xl.write(b); err != nil {
-
-			// Transmit the error to the main routine. The old error in 'errorChan'
-			// will block a new error entering 'errorChan'  before it's captured by
-			// the main routine, because the cache of 'errorChan' is limited. So we
-			// need to discard the old error at first, then write the new error to it.
-			// The following statements is not redundant, two read operations should
-			// be nonblocking.
+	for {
+		var (
+			r  record
+			ok bool
+		)
+
+		select {
+		case r, ok = <-xl.bc:
+		default:
+			// The buffer channel is empty, so the current batch is over.
+			if dirty && ticker == nil {
+				xl.flushSinks(false)
+				dirty = false
+			}
+
 			select {
-			case xl.errorChan <- err:
-			default:
-				select {
-				// Because the two read operations are not atomic, the error in
-				// 'errorChan' may have been read by the main routine, so I have
-				// to add 'default' statement to make this read operation nonblocking.
-				case <-xl.errorChan:
-					xl.errorChan <- err
-				default:
-					// nothing
+			case r, ok = <-xl.bc:
+			case <-tc:
+				if dirty {
+					xl.flushSinks(false)
+					dirty = false
 				}
+				continue
 			}
 		}
-	}
 
-	if xl.f != nil {
-		xl.f.Close()
+		if !ok {
+			break
+		}
+
+		if r.done != nil {
+			if r.reopen {
+				r.done <- xl.reopenSinks()
+			} else {
+				r.done <- xl.flushSinks(true)
+				dirty = false
+			}
+			continue
+		}
 
+		for _, s := range xl.sinks {
+			if !s.accept(r) {
+				continue
+			}
+			if err := s.write(r); err != nil {
+				xl.report(err)
+			}
+		}
+		dirty = true
+	}
+
+	// Closing a sink also flushes its buffered data.
+	for _, s := range xl.sinks {
+		s.close()
 	}
 	close(xl.errorChan)
 	close(xl.exitChan)
@@ -297,84 +519,216 @@ func (xl *XLogger) flush() {
 
 }
 
-// This function is not smiliar to 'Write' function, it's used to
-// write data to the file.
-func (xl *XLogger) write(b []byte) error {
-	var err error
-	// Init
-	if xl.f == nil {
-		if err = cleanup(xl.dir, xl.ma, xl.mb, xl.mb); err != nil {
-			return err
+// Flush the buffered data of all sinks. If 'sync' parameter is true, the
+// data will also be committed to the stable storage. The first error is
+// returned, and all errors are reported.
+func (xl *XLogger) flushSinks(sync bool) (first error) {
+	for _, s := range xl.sinks {
+		if err := s.flush(sync); err != nil {
+			xl.report(err)
+			if first == nil {
+				first = err
+			}
 		}
+	}
+	return
+}
 
-		if xl.f, err = openFile(xl.dir); err != nil {
-			return err
-		}
+// Sync blocks until all records enqueued before calling it have been
+// written to the sinks and committed to the disk. The FATAL records are
+// synchronized automatically. In the rare case that the 'drop_oldest'
+// overflow policy can't keep the barrier in the full buffer channel,
+// ErrDropped is returned.
+func (xl *XLogger) Sync() error {
+	return xl.barrier(false)
+}
+
+// Push a barrier to the buffer channel and wait for the result. The
+// barrier reopens the sinks if 'reopen' parameter is true, otherwise
+// synchronizes them.
+func (xl *XLogger) barrier(reopen bool) (err error) {
+	if xl == nil {
+		return nil
 	}
 
-	// The size of current log file exceeds the limit.
-	if xl.f == nil || (xl.ms > 0 && xl.f.Size >= xl.ms) {
-		// We must ensure that the data of old log file has been persisted
-		// before using the new log file.
-		if xl.f != nil {
-			if err = xl.f.Close(); err != nil {
-				return err
-			}
+	defer func() {
+		if x := recover(); x != nil {
+			err = ErrClosed
 		}
+	}()
 
-		// Because we will create a new file, so the number of files decreases one.
-		if err = cleanup(xl.dir, xl.ma, xl.mb, xl.mb-1); err != nil {
-			return err
-		}
+	// A barrier is always pushed by blocking regardless of the overflow
+	// policy, because it can't be dropped.
+	done := make(chan error, 1)
+	xl.bc <- record{done: done, reopen: reopen}
+	return <-done
+}
 
-		if xl.f, err = createFile(xl.dir); err != nil {
-			return err
+// Report an error of the flush routine to the main routine.
+func (xl *XLogger) report(err error) {
+	atomic.AddUint64(&xl.writeErrors, 1)
+	if xl.onError != nil {
+		xl.onError(err)
+		return
+	}
+
+	// Transmit the error to the main routine. The old error in 'errorChan'
+	// will block a new error entering 'errorChan'  before it's captured by
+	// the main routine, because the cache of 'errorChan' is limited. So we
+	// need to discard the old error at first, then write the new error to it.
+	// The following statements is not redundant, two read operations should
+	// be nonblocking.
+	select {
+	case xl.errorChan <- err:
+	default:
+		select {
+		// Because the two read operations are not atomic, the error in
+		// 'errorChan' may have been read by the main routine, so I have
+		// to add 'default' statement to make this read operation nonblocking.
+		case <-xl.errorChan:
+			xl.errorChan <- err
+		default:
+			// nothing
 		}
+	}
+}
 
+// Decorate the output information, equipped with some tags. The fields
+// of the XLogger itself are placed before the 'fields' parameter.
+func (xl *XLogger) output(level int, m string, fields []Field) (n int, err error) {
+	if xl == nil {
+		return 0, nil
 	}
 
-	_, err = xl.f.Write(b)
-	return err
+	var pc uintptr
+	if xl.needPC(level) {
+		pc = callerPC(2)
+	}
+	return xl.outputPC(level, pc, m, fields)
+}
+
+// Whether the program counter of the call site is needed by a record.
+func (xl *XLogger) needPC(level int) bool {
+	return xl.needLocation(level) || level <= xl.stackLevel || xl.sampling(level)
 }
 
-// Decorate the output information, equipped with some tags.
-func (xl *XLogger) output(level int, m string) (n int, err error) {
+// Whether a record is sampled by the sampler, only the records accepted by
+// the sinks are sampled, the others are written to the standard output
+// directly.
+func (xl *XLogger) sampling(level int) bool {
+	return xl.sampler != nil && xl.accept(level, xl.Level())
+}
+
+// Whether the location of a record is needed by the encoder.
+func (c *core) needLocation(level int) bool {
+	if ln, ok := c.encoder.(locationNeeder); ok {
+		return ln.NeedLocation(level)
+	}
+	return true
+}
+
+// Same as 'output' method, but the location of the record is specified by
+// the 'pc' parameter (see 'callerPC' function). It's used by the adapters
+// whose call site isn't at a fixed depth of the stack.
+func (xl *XLogger) outputPC(level int, pc uintptr, m string, fields []Field) (n int, err error) {
+	return xl.outputAt(time.Now(), level, pc, m, fields)
+}
+
+// Same as 'outputPC' method, but the time of the record is specified by the
+// 't' parameter. It's used by the adapters forwarding the records which have
+// their own timestamps.
+func (xl *XLogger) outputAt(t time.Time, level int, pc uintptr, m string, fields []Field) (n int, err error) {
 	if xl == nil {
 		return 0, nil
 	}
 
-	s := strings.Join([]string{
-		timeTag(),
-		"[", xl.tag, "]",
-		levelTags[level],
-		locationTag(level, 2),
-		":", m,
-	}, "")
+	if xl.sampling(level) && !xl.sampler.allow(xl, pc, level) {
+		atomic.AddUint64(&xl.sampled, 1)
+		return 0, nil
+	}
 
-	if level <= xl.level {
-		n, err = xl.Write([]byte(s))
+	e := &Entry{
+		Time:    t,
+		Level:   level,
+		Tag:     xl.tag,
+		Message: strings.TrimSuffix(m, "\n"),
+		Fields:  xl.appendFields(fields),
+	}
+	// The cause lists are only attached to the ERROR and FATAL records, so
+	// the records of lower priorities don't pay for walking the error chains.
+	if level <= ERROR {
+		e.Fields = expandCauses(e.Fields)
+	}
+	if xl.needLocation(level) {
+		e.Caller = pc2caller(pc)
+	}
+	if level <= xl.stackLevel {
+		e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], Field{"stack", callerStack(pc)})
+	}
+	return xl.write(e)
+}
+
+// Encode an Entry and write it to the sinks. If no sink accepts it, it will
+// be written to standard output.
+func (xl *XLogger) write(e *Entry) (n int, err error) {
+	s := xl.encoder.Encode(e)
+
+	if threshold := xl.Level(); xl.accept(e.Level, threshold) {
+		if n, err = xl.enqueue(record{e.Level, threshold, e.Time, s, nil, false}); err == nil && e.Level == FATAL {
+			err = xl.Sync()
+		}
 	} else {
-		n, err = fmt.Fprintf(os.Stdout, s)
+		n, err = os.Stdout.Write(s)
 	}
 	return
 }
 
 type file struct {
-	Fp   *os.File
-	Size int64
+	Fp         *os.File
+	Size       int64
+	CreateTime time.Time
+
+	// Buffer the data written to Fp, so a batch of records can be written
+	// by one system call.
+	buf *bufio.Writer
 }
 
-func createFile(dir string) (*file, error) {
+// The size of the buffer of a log file.
+const fileBufferSize = 32 * 1024
+
+// Create a new log file whose creation time is 't'. If the name has been used
+// by another file (including the compressed one), the creation time will be
+// increased by one nanosecond until the name is unused.
+func createFile(dir string, t time.Time) (*file, error) {
 	var (
 		err error
-		f   = &file{}
+		f   = &file{CreateTime: t}
 	)
 
-	f.Fp, err = os.OpenFile(getName(dir, time.Now()), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	for {
+		name := getName(dir, f.CreateTime)
+		if _, err = os.Stat(name + compressSuffix); os.IsNotExist(err) {
+			f.Fp, err = os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0666)
+			if !os.IsExist(err) {
+				break
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		f.CreateTime = f.CreateTime.Add(time.Nanosecond)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
+	// Mark the file is active, so other processes sharing the directory
+	// won't touch it.
+	if err = flock(f.Fp, false); err != nil {
+		f.Fp.Close()
+		return nil, err
+	}
+
 	f.Size = int64(0)
 
 	return f, nil
@@ -392,7 +746,8 @@ func openFile(dir string) (*file, error) {
 	}
 	fiq.Sort()
 
-	if !fiq.IsEmpty() {
+	// A compressed file can't be appended, so creating a new file.
+	if !fiq.IsEmpty() && !fiq.Last().Compressed {
 		fi := fiq.Last()
 		// Don't create a new file by default.
 		f.Fp, err = os.OpenFile(getName(dir, fi.CreateTime), os.O_WRONLY|os.O_APPEND, 0666)
@@ -400,7 +755,16 @@ func openFile(dir string) (*file, error) {
 			return nil, err
 		}
 
-		f.Size = fi.Size
+		// The file is being written by another process, so creating a new file.
+		if err = flock(f.Fp, false); err != nil {
+			f.Fp.Close()
+			if err == errLocked {
+				err = nil
+			}
+			return nil, err
+		}
+
+		f.Size, f.CreateTime = fi.Size, fi.CreateTime
 
 		return f, nil
 	}
@@ -427,34 +791,66 @@ func cleanup(dir string, ma time.Duration, mb, amb int64) error {
 }
 
 func (f *file) Write(b []byte) (int, error) {
-	n, err := f.Fp.Write(b)
+	if f.buf == nil {
+		f.buf = bufio.NewWriterSize(f.Fp, fileBufferSize)
+	}
+	n, err := f.buf.Write(b)
 	f.Size += int64(n)
 	return n, err
 }
 
+// Flush the buffered data to the file. If 'sync' parameter is true, the
+// data will also be committed to the stable storage.
+func (f *file) Flush(sync bool) (err error) {
+	if f.buf != nil {
+		err = f.buf.Flush()
+	}
+	if err == nil && sync {
+		err = f.Fp.Sync()
+	}
+	return
+}
+
 func (f *file) Close() error {
-	return f.Fp.Close()
+	err := f.Flush(false)
+	if cerr := f.Fp.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 type fileInfo struct {
 	CreateTime time.Time
 	Size       int64
+	Compressed bool
+}
+
+// Get the file name (exclude the directory) of a fileInfo.
+func (fi fileInfo) Name() string {
+	if fi.Compressed {
+		return time2name(fi.CreateTime) + compressSuffix
+	}
+	return time2name(fi.CreateTime)
 }
 
 type fileInfoQueue []fileInfo
 
 func createFileInfoQueue(dir string) (*fileInfoQueue, error) {
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		return nil, err
+	}
+	return readFileInfoQueue(dir)
+}
+
+// Same as 'createFileInfoQueue' function, but the directory isn't created if
+// it doesn't exist, it's used by the read-only operations.
+func readFileInfoQueue(dir string) (*fileInfoQueue, error) {
 	var (
 		err error
 		sts []os.FileInfo
 		fiq fileInfoQueue
 	)
 
-	err = os.MkdirAll(dir, 0744)
-	if err != nil {
-		return nil, err
-	}
-
 	// Get the state of a file.
 	sts, err = ioutil.ReadDir(dir)
 	if err != nil {
@@ -464,7 +860,7 @@ func createFileInfoQueue(dir string) (*fileInfoQueue, error) {
 	fiq = make([]fileInfo, 0, len(sts))
 	for _, st := range sts {
 		if !st.IsDir() && isValidName(st.Name()) {
-			fiq = append(fiq, fileInfo{name2time(st.Name()), st.Size()})
+			fiq = append(fiq, fileInfo{name2time(st.Name()), st.Size(), strings.HasSuffix(st.Name(), compressSuffix)})
 		}
 	}
 
@@ -528,18 +924,24 @@ func (fiq *fileInfoQueue) CleanUp(dir string, ma time.Duration, mb, amb int64) {
 	*fiq = (*fiq)[i:]
 
 	for _, info := range removes {
-		os.Remove(filepath.Join(dir, time2name(info.CreateTime)))
+		// The active files of other processes are kept.
+		if !isActive(dir, info) {
+			os.Remove(filepath.Join(dir, info.Name()))
+		}
 	}
 }
 
+// The standard file name, a compressed file has the '.gz' suffix.
+var reName = regexp.MustCompile(`^\d{4}(_\d{2}){5}_\d{9}(\.gz)?$`)
+
 func isValidName(name string) bool {
-	matched, _ := regexp.MatchString(`^\d{4}(_\d{2}){5}_\d{9}$`, name)
-	return matched
+	return reName.MatchString(name)
 }
 
 // Convert the standard file name to the time struct.
 func name2time(name string) time.Time {
 	var year, month, day, hour, min, sec, nsec int
+	name = strings.TrimSuffix(name, compressSuffix)
 	name = strings.Replace(name, "_", " ", -1)
 	fmt.Sscanf(name, "%d%d%d%d%d%d%d", &year, &month, &day, &hour, &min, &sec, &nsec)
 	t := time.Date(year, time.Month(month), day, hour, min, sec, nsec, time.Local)
@@ -558,26 +960,6 @@ func getName(dir string, t time.Time) string {
 	return filepath.Join(dir, time2name(t))
 }
 
-func timeTag() string {
-	t := time.Now()
-	return fmt.Sprintf("[%04d-%02d-%02d %02d:%02d:%02d]",
-		int(t.Year()), int(t.Month()), int(t.Day()), int(t.Hour()), int(t.Minute()), int(t.Second()))
-}
-
-// Location tag, 'Info' function won't use it.
-func locationTag(level int, skip int) string {
-	if level == INFO {
-		return ""
-	}
-
-	pc, file, line, ok := runtime.Caller(skip + 1)
-	if !ok {
-		return "[]" // Location failure.
-	}
-	f := runtime.FuncForPC(pc)
-	return fmt.Sprintf("[%s(%d) - %s]", filepath.Base(file), line, f.Name())
-}
-
 // Check whether the user has the writeable privilege on the special directory
 // by creating a new temp file on it, it's the surest way. If we depend on file
 // bit, it will be failed when the file system mounted is read-only or exists
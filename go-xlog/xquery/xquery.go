@@ -0,0 +1,368 @@
+// xquery.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+// Package xquery queries the records of an xlog directory. It streams the
+// records in chronological order across the rotated (and compressed) log
+// files, filters them by time, level and tag, and can follow the active log
+// file like 'tail -f' across rotations.
+package xquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/X-Plan/xgo/go-xlog"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is a record parsed from a line of a log file.
+type Entry struct {
+	// The following three fields are zero values if the line can't be
+	// parsed, for example, the line is written by 'Write' method of XLogger.
+	Time  time.Time
+	Tag   string
+	Level int
+
+	// The raw line without the trailing '\n'.
+	Line string
+}
+
+// The string format of an Entry is its raw line.
+func (e *Entry) String() string {
+	return e.Line
+}
+
+// The layout of the timestamp used by the text format of xlog by default.
+const TextTimeFormat = "2006-01-02 15:04:05"
+
+// The prefix of the text format: [time][tag][LEVEL]
+var reText = regexp.MustCompile(`^\[([^\]]*)\]\[([^\]]*)\]\[(FATAL|ERROR|WARN|INFO|DEBUG)\]`)
+
+// Parse a line written by the builtin encoders of xlog, the format is detected
+// automatically. The 'timeFormat' parameter is the layout of the timestamp,
+// if it's empty, the default layout of the format will be used. The timestamp
+// without time zone is parsed as local time. A line which can't be parsed
+// is still returned, but only its 'Line' field is set.
+func Parse(line string, timeFormat string) *Entry {
+	e := &Entry{Line: strings.TrimSuffix(line, "\n")}
+
+	switch {
+	case strings.HasPrefix(e.Line, "["):
+		if results := reText.FindStringSubmatch(e.Line); results != nil {
+			e.Tag, e.Level = results[2], ParseLevel(results[3])
+			e.Time = parseTime(results[1], timeFormat, TextTimeFormat)
+		}
+	case strings.HasPrefix(e.Line, "{"):
+		var m struct {
+			Time  string `json:"time"`
+			Tag   string `json:"tag"`
+			Level string `json:"level"`
+		}
+		if json.Unmarshal([]byte(e.Line), &m) == nil {
+			e.Tag, e.Level = m.Tag, ParseLevel(m.Level)
+			e.Time = parseTime(m.Time, timeFormat, time.RFC3339)
+		}
+	case strings.HasPrefix(e.Line, "time="):
+		kvs := parseLogfmt(e.Line)
+		e.Tag, e.Level = kvs["tag"], ParseLevel(kvs["level"])
+		e.Time = parseTime(kvs["time"], timeFormat, time.RFC3339)
+	}
+	return e
+}
+
+// ParseLevel converts the name of a level (case insensitive) to the level of
+// xlog, zero represents the name is invalid.
+func ParseLevel(name string) int {
+	switch strings.ToUpper(name) {
+	case "FATAL":
+		return xlog.FATAL
+	case "ERROR":
+		return xlog.ERROR
+	case "WARN":
+		return xlog.WARN
+	case "INFO":
+		return xlog.INFO
+	case "DEBUG":
+		return xlog.DEBUG
+	}
+	return 0
+}
+
+func parseTime(str, layout, def string) time.Time {
+	if layout == "" {
+		layout = def
+	}
+	t, _ := time.ParseInLocation(layout, str, time.Local)
+	return t
+}
+
+// Parse the keys and values of a logfmt line, a value may be quoted.
+func parseLogfmt(line string) map[string]string {
+	kvs := make(map[string]string)
+	for line != "" {
+		i := strings.IndexByte(line, '=')
+		if i <= 0 {
+			break
+		}
+		key, rest := line[:i], line[i+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				break
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else if j := strings.IndexByte(rest, ' '); j >= 0 {
+			value, rest = rest[:j], rest[j:]
+		} else {
+			value, rest = rest, ""
+		}
+
+		kvs[key] = value
+		line = strings.TrimLeft(rest, " ")
+	}
+	return kvs
+}
+
+// Filter decides which entries are selected. The zero value selects all
+// entries.
+type Filter struct {
+	// Only the entries in the time range [Start, End) are selected, the zero
+	// value represents unbounded. The entries without timestamp are always
+	// selected by the time range.
+	Start time.Time
+	End   time.Time
+
+	// Only the entries whose priority is higher than or equal to this level
+	// are selected, zero represents all levels.
+	Level int
+
+	// Only the entries whose tag is one of these tags are selected, a tag also
+	// selects its children created by 'Named' method of XLogger, for example,
+	// 'db' selects 'db' and 'db.conn'. Empty represents all tags.
+	Tags []string
+}
+
+// Match reports whether the entry is selected by the filter.
+func (f *Filter) Match(e *Entry) bool {
+	if f.Level != 0 && (e.Level == 0 || e.Level > f.Level) {
+		return false
+	}
+
+	if len(f.Tags) > 0 {
+		var ok bool
+		for _, tag := range f.Tags {
+			if e.Tag == tag || strings.HasPrefix(e.Tag, tag+".") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if !e.Time.IsZero() {
+		if !f.Start.IsZero() && e.Time.Before(f.Start) {
+			return false
+		}
+		if !f.End.IsZero() && !e.Time.Before(f.End) {
+			return false
+		}
+	}
+	return true
+}
+
+// Query streams the entries of an xlog directory.
+type Query struct {
+	Filter
+
+	// The directory of the log files.
+	Dir string
+
+	// The layout of the timestamp, see 'Parse' function.
+	TimeFormat string
+
+	// Whether following the active log file like 'tail -f'. If it's true,
+	// 'Run' method waits for new entries after reading all existing entries,
+	// and switches to the new log file after a rotation.
+	Follow bool
+
+	// The interval of checking new entries when following the active log file.
+	// If it's zero, one second will be used by default.
+	Interval time.Duration
+}
+
+// Run streams the selected entries to the 'handle' function in chronological
+// order, it returns when all entries are handled or the 'handle' function
+// returns an error. If 'Follow' field is true, it only returns when the 'exit'
+// channel is closed or an error occurs.
+func (q *Query) Run(exit chan int, handle func(*Entry) error) (err error) {
+	var (
+		files  []xlog.LogFile
+		rc     io.ReadCloser
+		latest time.Time // The creation time of the latest log file opened.
+		s      = &scanner{q: q, handle: handle}
+	)
+
+	defer func() {
+		if rc != nil {
+			rc.Close()
+		}
+	}()
+
+	if files, err = xlog.ListFiles(q.Dir, q.Start, q.End); err != nil {
+		return
+	}
+
+outer:
+	for {
+		for _, f := range files {
+			// The previous log file has been closed by the XLogger when
+			// the next one exists, so it can be finished.
+			if rc != nil {
+				err, rc = s.finish(rc), nil
+				if err != nil {
+					return
+				}
+			}
+
+			if rc, err = f.Open(); err != nil {
+				return
+			}
+			latest = f.CreateTime
+
+			if err = s.copy(rc); err != nil {
+				return
+			}
+		}
+
+		if !q.Follow {
+			break
+		}
+
+		select {
+		case <-exit:
+			break outer
+		case <-time.After(q.interval()):
+		}
+
+		// Read the new entries of the active log file.
+		if rc != nil {
+			if err = s.copy(rc); err != nil {
+				return
+			}
+		}
+
+		if files, err = newerFiles(q.Dir, latest); err != nil {
+			return
+		}
+	}
+
+	if rc != nil {
+		err, rc = s.finish(rc), nil
+	}
+	return
+}
+
+func (q *Query) interval() time.Duration {
+	if q.Interval > 0 {
+		return q.Interval
+	}
+	return time.Second
+}
+
+// Get the log files created after the given time.
+func newerFiles(dir string, t time.Time) ([]xlog.LogFile, error) {
+	files, err := xlog.ListFiles(dir, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range files {
+		if f.CreateTime.After(t) {
+			return files[i:], nil
+		}
+	}
+	return nil, nil
+}
+
+// scanner splits the data of log files into lines, and passes the selected
+// entries to the handle function.
+type scanner struct {
+	q       *Query
+	handle  func(*Entry) error
+	pending []byte // The incomplete line of the current log file.
+	buf     []byte
+}
+
+// Read the data of a log file until EOF, the incomplete line is kept, because
+// it may be completed later.
+func (s *scanner) copy(r io.Reader) error {
+	if s.buf == nil {
+		s.buf = make([]byte, 32*1024)
+	}
+
+	for {
+		n, err := r.Read(s.buf)
+		if n > 0 {
+			if herr := s.feed(s.buf[:n]); herr != nil {
+				return herr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// Read the remaining data of a log file and close it, the incomplete line is
+// handled as a complete line.
+func (s *scanner) finish(rc io.ReadCloser) error {
+	err := s.copy(rc)
+	if cerr := rc.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil && len(s.pending) > 0 {
+		line := string(s.pending)
+		s.pending = s.pending[:0]
+		err = s.line(line)
+	}
+	return err
+}
+
+func (s *scanner) feed(data []byte) error {
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			s.pending = append(s.pending, data...)
+			return nil
+		}
+
+		line := string(append(s.pending, data[:i]...))
+		s.pending, data = s.pending[:0], data[i+1:]
+		if err := s.line(line); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *scanner) line(line string) error {
+	if e := Parse(line, s.q.TimeFormat); s.q.Match(e) {
+		return s.handle(e)
+	}
+	return nil
+}
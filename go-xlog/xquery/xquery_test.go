@@ -0,0 +1,210 @@
+// xquery_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xquery
+
+import (
+	"fmt"
+	"github.com/X-Plan/xgo/go-xassert"
+	"github.com/X-Plan/xgo/go-xlog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	for _, format := range []string{xlog.FormatText, xlog.FormatJSON, xlog.FormatLogfmt} {
+		enc, err := xlog.NewEncoder(format, xlog.EncoderConfig{})
+		xassert.IsNil(t, err)
+
+		data := enc.Encode(&xlog.Entry{
+			Time:    now,
+			Tag:     "app.db",
+			Level:   xlog.WARN,
+			Message: "hello world",
+			Fields:  []xlog.Field{{Key: "user", Value: "blinklv"}},
+		})
+
+		e := Parse(string(data), "")
+		xassert.Equal(t, e.Line, strings.TrimSuffix(string(data), "\n"))
+		xassert.Equal(t, e.Tag, "app.db")
+		xassert.Equal(t, e.Level, xlog.WARN)
+		xassert.IsTrue(t, e.Time.Equal(now))
+	}
+
+	// The lines can't be parsed.
+	for _, line := range []string{"", "hello world", "[app][INFO]:x", "{bad json}", "time=bad"} {
+		e := Parse(line, "")
+		xassert.Equal(t, e.Line, line)
+		xassert.Equal(t, e.Tag, "")
+		xassert.Equal(t, e.Level, 0)
+		xassert.IsTrue(t, e.Time.IsZero())
+	}
+
+	// The timestamp can't be parsed, but the others can.
+	e := Parse("[bad][app][INFO]:hello", "")
+	xassert.IsTrue(t, e.Time.IsZero())
+	xassert.Equal(t, e.Tag, "app")
+	xassert.Equal(t, e.Level, xlog.INFO)
+
+	// Customized time format.
+	e = Parse("[2018/08/24 10:00:00][app][INFO]:hello", "2006/01/02 15:04:05")
+	xassert.IsTrue(t, e.Time.Equal(time.Date(2018, 8, 24, 10, 0, 0, 0, time.Local)))
+
+	xassert.Equal(t, ParseLevel("warn"), xlog.WARN)
+	xassert.Equal(t, ParseLevel("Debug"), xlog.DEBUG)
+	xassert.Equal(t, ParseLevel("trace"), 0)
+}
+
+func TestFilter(t *testing.T) {
+	base := time.Date(2018, 8, 24, 10, 0, 0, 0, time.Local)
+	f := &Filter{
+		Start: base,
+		End:   base.Add(time.Hour),
+		Level: xlog.WARN,
+		Tags:  []string{"db", "http"},
+	}
+
+	for _, c := range []struct {
+		e  *Entry
+		ok bool
+	}{
+		{&Entry{Time: base, Tag: "db", Level: xlog.WARN}, true},
+		{&Entry{Time: base, Tag: "db.conn", Level: xlog.ERROR}, true},
+		{&Entry{Tag: "http", Level: xlog.FATAL}, true},
+		{&Entry{Time: base, Tag: "dbx", Level: xlog.WARN}, false},
+		{&Entry{Time: base, Tag: "app", Level: xlog.WARN}, false},
+		{&Entry{Time: base, Tag: "db", Level: xlog.INFO}, false},
+		{&Entry{Time: base, Tag: "db"}, false},
+		{&Entry{Time: base.Add(-time.Second), Tag: "db", Level: xlog.WARN}, false},
+		{&Entry{Time: base.Add(time.Hour), Tag: "db", Level: xlog.WARN}, false},
+	} {
+		xassert.Equal(t, f.Match(c.e), c.ok)
+	}
+
+	// The zero value selects all entries.
+	xassert.IsTrue(t, (&Filter{}).Match(&Entry{Line: "hello world"}))
+}
+
+func TestRun(t *testing.T) {
+	dir := "/tmp/xquery_run"
+	defer os.RemoveAll(dir)
+
+	xl, err := xlog.New(&xlog.XConfig{
+		Dir:        dir,
+		Tag:        "app",
+		Level:      xlog.DEBUG,
+		MaxSize:    256,
+		MaxBackups: 100,
+		Compress:   true,
+		Format:     xlog.FormatJSON,
+	})
+	xassert.IsNil(t, err)
+
+	db := xl.Named("db")
+	for i := 0; i < 20; i++ {
+		xassert.IsNil(t, xl.Info("app %d", i))
+		xassert.IsNil(t, db.Warn("db %d", i))
+	}
+	xassert.IsNil(t, xl.Close())
+
+	files, err := xlog.ListFiles(dir, time.Time{}, time.Time{})
+	xassert.IsNil(t, err)
+	xassert.IsTrue(t, len(files) > 1)
+
+	messages := func(q *Query) []string {
+		var result []string
+		xassert.IsNil(t, q.Run(nil, func(e *Entry) error {
+			result = append(result, e.Line)
+			return nil
+		}))
+		return result
+	}
+
+	lines := messages(&Query{Dir: dir})
+	xassert.Equal(t, len(lines), 40)
+	for i := 0; i < 20; i++ {
+		xassert.IsTrue(t, strings.Contains(lines[2*i], fmt.Sprintf(`"app %d"`, i)))
+		xassert.IsTrue(t, strings.Contains(lines[2*i+1], fmt.Sprintf(`"db %d"`, i)))
+	}
+
+	// A tag also selects its children.
+	lines = messages(&Query{Dir: dir, Filter: Filter{Tags: []string{"app"}}})
+	xassert.Equal(t, len(lines), 40)
+
+	lines = messages(&Query{Dir: dir, Filter: Filter{Tags: []string{"app.db"}}})
+	xassert.Equal(t, len(lines), 20)
+
+	lines = messages(&Query{Dir: dir, Filter: Filter{Level: xlog.WARN}})
+	xassert.Equal(t, len(lines), 20)
+
+	lines = messages(&Query{Dir: dir, Filter: Filter{Start: time.Now().Add(time.Minute)}})
+	xassert.Equal(t, len(lines), 0)
+
+	// The 'handle' function stops the query.
+	stop := fmt.Errorf("stop")
+	xassert.Equal(t, (&Query{Dir: dir}).Run(nil, func(*Entry) error { return stop }), stop)
+}
+
+func TestFollow(t *testing.T) {
+	dir := "/tmp/xquery_follow"
+	defer os.RemoveAll(dir)
+
+	xl, err := xlog.New(&xlog.XConfig{
+		Dir:        dir,
+		Tag:        "app",
+		Level:      xlog.INFO,
+		MaxSize:    256,
+		MaxBackups: 100,
+	})
+	xassert.IsNil(t, err)
+	defer xl.Close()
+
+	xassert.IsNil(t, xl.Info("old"))
+	xassert.IsNil(t, xl.Sync())
+
+	var (
+		lines = make(chan string, 64)
+		exit  = make(chan int)
+		done  = make(chan error, 1)
+		q     = &Query{Dir: dir, Follow: true, Interval: 10 * time.Millisecond}
+	)
+	go func() {
+		done <- q.Run(exit, func(e *Entry) error {
+			lines <- e.Line
+			return nil
+		})
+	}()
+
+	receive := func(message string) {
+		select {
+		case line := <-lines:
+			xassert.IsTrue(t, strings.HasSuffix(line, ":"+message))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("wait for '%s' timeout", message)
+		}
+	}
+	receive("old")
+
+	// The records span several log files because of the rotation.
+	for i := 0; i < 20; i++ {
+		xassert.IsNil(t, xl.Info("new %d", i))
+		xassert.IsNil(t, xl.Sync())
+	}
+	for i := 0; i < 20; i++ {
+		receive(fmt.Sprintf("new %d", i))
+	}
+
+	files, err := xlog.ListFiles(dir, time.Time{}, time.Time{})
+	xassert.IsNil(t, err)
+	xassert.IsTrue(t, len(files) > 1)
+
+	close(exit)
+	xassert.IsNil(t, <-done)
+}
@@ -0,0 +1,33 @@
+// context.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xp
+
+import (
+	"context"
+	"github.com/X-Plan/xgo/go-xlog"
+	"strconv"
+)
+
+// NewContext returns a copy of the parent context seeded with the request ID
+// of an X-Protocol request, the request ID is the sequence of the request
+// header. The client ID is also carried if it isn't empty. Handlers can pass
+// the result to the context-aware methods of xlog.XLogger.
+//
+//	func handle(req *xp.Request) (*xp.Response, error) {
+//		ctx := xp.NewContext(context.Background(), req)
+//		xl.InfoContext(ctx, "handle request")
+//		...
+//	}
+func NewContext(ctx context.Context, req *Request) context.Context {
+	head := req.GetHead()
+	kvs := []interface{}{xlog.RequestIDKey, strconv.FormatUint(head.GetSequence(), 10)}
+	if id := head.GetClientId(); id != "" {
+		kvs = append(kvs, "client_id", id)
+	}
+	return xlog.NewContext(ctx, kvs...)
+}
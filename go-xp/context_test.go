@@ -0,0 +1,124 @@
+// context_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xp
+
+import (
+	"bytes"
+	"context"
+	"github.com/X-Plan/xgo/go-xassert"
+	"github.com/X-Plan/xgo/go-xlog"
+	"github.com/X-Plan/xgo/go-xpacket"
+	"github.com/golang/protobuf/proto"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewContext(t *testing.T) {
+	ctx := NewContext(context.Background(), &Request{Head: &Header{Sequence: 10, ClientId: "c1"}})
+	xassert.Equal(t, xlog.RequestIDFromContext(ctx), "10")
+	xassert.Equal(t, xlog.FieldsFromContext(ctx), []xlog.Field{
+		{Key: xlog.RequestIDKey, Value: "10"},
+		{Key: "client_id", Value: "c1"},
+	})
+
+	// The client ID is omitted if it's empty.
+	ctx = NewContext(context.Background(), &Request{Head: &Header{Sequence: 11}})
+	xassert.Equal(t, xlog.FieldsFromContext(ctx), []xlog.Field{{Key: xlog.RequestIDKey, Value: "11"}})
+
+	// The fields reach the logger.
+	xl, buf := newTestLogger(t, "/tmp/xp_context")
+	defer os.RemoveAll("/tmp/xp_context")
+	ctx = NewContext(context.Background(), &Request{Head: &Header{Sequence: 12, ClientId: "c2"}})
+	xassert.IsNil(t, xl.InfoContext(ctx, "handle request"))
+	xassert.IsNil(t, xl.Close())
+	xassert.Equal(t, buf.String(), "handle request request_id=12 client_id=c2\n")
+}
+
+// The failures of handling requests are logged with the request IDs.
+func TestRouterErrorRequest(t *testing.T) {
+	xl, buf := newTestLogger(t, "/tmp/xp_router_error")
+	defer os.RemoveAll("/tmp/xp_router_error")
+
+	router := &Router{Logger: xl}
+	router.Bind(genHandlerPair(1000, 1000, Code_OK))
+	router.Bind(genHandlerPair(1000, 2000, Code_REQUEST_ERROR))
+
+	// The reading routine of the router outlives 'Handle' method, a TCP
+	// connection makes it exit quietly when the connection is closed.
+	l, port := freeListener(t)
+	defer l.Close()
+	done := make(chan int)
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			router.Handle(conn, make(chan int))
+		}
+		close(done)
+	}()
+	client, err := net.Dial("tcp", "127.0.0.1:"+port)
+	xassert.IsNil(t, err)
+
+	elements := []struct {
+		cmd, subcmd uint32
+		seq         uint64
+		code        Code
+	}{
+		{1000, 1000, 20, Code_OK},
+		{3000, 1000, 21, Code_REQUEST_ERROR},
+		// The router closes the connection after a server error.
+		{1000, 2000, 22, Code_SERVER_ERROR},
+	}
+	for _, element := range elements {
+		data, err := proto.Marshal(&Request{Head: &Header{Sequence: element.seq, Cmd: element.cmd, SubCmd: element.subcmd, ClientId: "c1"}})
+		xassert.IsNil(t, err)
+		xassert.IsNil(t, xpacket.Encode(client, data))
+
+		data, err = xpacket.Decode(client)
+		xassert.IsNil(t, err)
+		rsp := &Response{}
+		xassert.IsNil(t, proto.Unmarshal(data, rsp))
+		xassert.Equal(t, rsp.GetHead().GetSequence(), element.seq)
+		xassert.Equal(t, rsp.GetRet().GetCode(), int32(element.code))
+	}
+
+	xassert.IsNil(t, client.Close())
+	<-done
+	xassert.IsNil(t, xl.Close())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	xassert.Equal(t, len(lines), 2)
+	xassert.Equal(t, lines[0], "handle request failed (invalid cmd/subcmd (3000/1000)) request_id=21 client_id=c1")
+	xassert.IsTrue(t, strings.HasPrefix(lines[1], "handle request failed"))
+	xassert.IsTrue(t, strings.HasSuffix(lines[1], " request_id=22 client_id=c1"))
+}
+
+// Create an XLogger which writes the messages and the fields of the records to
+// the returned buffer, the buffer can only be read after closing the XLogger.
+func newTestLogger(t *testing.T, dir string) (*xlog.XLogger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	xl, err := xlog.New(&xlog.XConfig{
+		Dir:     dir,
+		Level:   xlog.DEBUG,
+		Encoder: messageEncoder{},
+		Sinks:   []xlog.SinkConfig{{Type: "writer", Writer: buf}},
+	})
+	xassert.IsNil(t, err)
+	return xl, buf
+}
+
+// messageEncoder encodes a record to its message and fields.
+type messageEncoder struct{}
+
+func (messageEncoder) Encode(e *xlog.Entry) []byte {
+	b := []byte(e.Message)
+	for _, f := range e.Fields {
+		b = append(b, " "+f.Key+"="+f.Value.(string)...)
+	}
+	return append(b, '\n')
+}
@@ -3,11 +3,12 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2017-11-03
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2017-12-07
+// Last Change: 2026-10-17
 
 package xp
 
 import (
+	"context"
 	"fmt"
 	"github.com/X-Plan/xgo/go-xlog"
 	"github.com/X-Plan/xgo/go-xpacket"
@@ -100,6 +101,13 @@ func (r *Router) errorf(format string, args ...interface{}) {
 	}
 }
 
+// Same as 'errorf' method, but the record carries the request ID.
+func (r *Router) errorRequest(req *Request, format string, args ...interface{}) {
+	if r.Logger != nil {
+		r.Logger.ErrorContext(NewContext(context.Background(), req), format, args...)
+	}
+}
+
 type connWrapper struct {
 	conn     net.Conn
 	queue    chan *Request
@@ -140,7 +148,7 @@ outer:
 		select {
 		case req = <-cw.queue:
 			if err, code = cw.handle(req); err != nil {
-				cw.r.errorf("handle request failed (%s)", err)
+				cw.r.errorRequest(req, "handle request failed (%s)", err)
 			}
 
 			if code == Code_SERVER_ERROR {
@@ -165,7 +173,7 @@ outer:
 	close(cw.queue)
 	for req = range cw.queue {
 		if err, _ = cw.handle(req); err != nil {
-			cw.r.errorf("handle request failed (%s)", err)
+			cw.r.errorRequest(req, "handle request failed (%s)", err)
 		}
 	}
 
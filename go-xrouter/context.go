@@ -0,0 +1,37 @@
+// context.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xrouter
+
+import (
+	"github.com/X-Plan/xgo/go-xlog"
+	"github.com/X-Plan/xgo/go-xrandstring"
+	"net/http"
+)
+
+// The header carrying the request ID of an HTTP request.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID wraps an XHandle, the context of the request passed to it carries
+// the request ID as a field of xlog (see 'NewContext' function of xlog package).
+// The request ID comes from the 'X-Request-Id' header of the incoming request,
+// a random one will be generated if the header is empty. The request ID is
+// also set to the header of the response.
+//
+//	xr.Handle("GET", "/user/:name", xrouter.RequestID(func(w http.ResponseWriter, r *http.Request, xps xrouter.XParams) {
+//		xl.InfoContext(r.Context(), "get user %s", xps.Get("name"))
+//	}))
+func RequestID(handle XHandle) XHandle {
+	return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = xrandstring.Get(16)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		handle(w, r.WithContext(xlog.NewContext(r.Context(), xlog.RequestIDKey, id)), xps)
+	}
+}
@@ -0,0 +1,35 @@
+// context_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+package xrouter
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"github.com/X-Plan/xgo/go-xlog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var id string
+	handle := RequestID(func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		id = xlog.RequestIDFromContext(r.Context())
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(RequestIDHeader, "9b2c0e1d")
+	w := httptest.NewRecorder()
+	handle(w, r, nil)
+	xassert.Equal(t, id, "9b2c0e1d")
+	xassert.Equal(t, w.Header().Get(RequestIDHeader), "9b2c0e1d")
+
+	// Generate a random request ID if the header is empty.
+	w = httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/", nil), nil)
+	xassert.Equal(t, len(id), 16)
+	xassert.Equal(t, w.Header().Get(RequestIDHeader), id)
+}
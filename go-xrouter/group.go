@@ -0,0 +1,139 @@
+// group.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xrouter
+
+import (
+	"strings"
+)
+
+// XGroup is a set of routes sharing a path prefix, middlewares and a Scope.
+// The routes registered through an XGroup are stored in its XRouter (or XTx),
+// so the default ones can also be removed by the 'Remove' method of the
+// XRouter with the full path.
+type XGroup struct {
+	reg         registrar
+	prefix      string
+	middlewares []Middleware
+	scope       Scope
+}
+
+// Group returns an XGroup whose routes are prefixed with the given prefix and
+// wrapped by the given middlewares. The middlewares are applied in order, so
+// the first one is the outermost one.
+//
+//	api := xr.Group("/api/v1", xrouter.RequestID)
+//	api.GET("/user/:name", GetUser) // GET /api/v1/user/:name
+func (xr *XRouter) Group(prefix string, middlewares ...Middleware) *XGroup {
+	return &XGroup{
+		reg:         xr,
+		prefix:      strings.TrimSuffix(prefix, "/"),
+		middlewares: middlewares,
+	}
+}
+
+// Group returns a nested XGroup, its prefix is appended to the prefix of the
+// parent, and its middlewares are wrapped by the middlewares of the parent.
+// It has the same Scope as the parent.
+func (g *XGroup) Group(prefix string, middlewares ...Middleware) *XGroup {
+	mws := make([]Middleware, 0, len(g.middlewares)+len(middlewares))
+	return &XGroup{
+		reg:         g.reg,
+		prefix:      g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(append(mws, g.middlewares...), middlewares...),
+		scope:       g.scope,
+	}
+}
+
+// registrar is the target of an XGroup, it's implemented by XRouter and XTx.
+type registrar interface {
+	handle(s Scope, method, path string, handle XHandle) error
+	handleNamed(s Scope, name, method, path string, handle XHandle) error
+	any(s Scope, path string, handle XHandle) error
+	remove(s Scope, method, path string) error
+}
+
+// Prefix returns the path prefix of the XGroup.
+func (g *XGroup) Prefix() string {
+	return g.prefix
+}
+
+// Scope returns the Scope of the XGroup.
+func (g *XGroup) Scope() Scope {
+	return g.scope
+}
+
+// GET is a shortcut for Handle("GET", path, handle)
+func (g *XGroup) GET(path string, handle XHandle) error {
+	return g.Handle("GET", path, handle)
+}
+
+// POST is a shortcut for Handle("POST", path, handle)
+func (g *XGroup) POST(path string, handle XHandle) error {
+	return g.Handle("POST", path, handle)
+}
+
+// HEAD is a shortcut for Handle("HEAD", path, handle)
+func (g *XGroup) HEAD(path string, handle XHandle) error {
+	return g.Handle("HEAD", path, handle)
+}
+
+// PUT is a shortcut for Handle("PUT", path, handle)
+func (g *XGroup) PUT(path string, handle XHandle) error {
+	return g.Handle("PUT", path, handle)
+}
+
+// OPTIONS is a shortcut for Handle("OPTIONS", path, handle)
+func (g *XGroup) OPTIONS(path string, handle XHandle) error {
+	return g.Handle("OPTIONS", path, handle)
+}
+
+// CONNECT is a shortcut for Handle("CONNECT", path, handle)
+func (g *XGroup) CONNECT(path string, handle XHandle) error {
+	return g.Handle("CONNECT", path, handle)
+}
+
+// TRACE is a shortcut for Handle("TRACE", path, handle)
+func (g *XGroup) TRACE(path string, handle XHandle) error {
+	return g.Handle("TRACE", path, handle)
+}
+
+// PATCH is a shortcut for Handle("PATCH", path, handle)
+func (g *XGroup) PATCH(path string, handle XHandle) error {
+	return g.Handle("PATCH", path, handle)
+}
+
+// DELETE is a shortcut for Handle("DELETE", path, handle)
+func (g *XGroup) DELETE(path string, handle XHandle) error {
+	return g.Handle("DELETE", path, handle)
+}
+
+// Handle registers a new request handle with the given method and the path
+// relative to the prefix of the XGroup, the handle is wrapped by the
+// middlewares of the XGroup. The syntax of the path is same as the one of
+// 'Handle' method of XRouter.
+func (g *XGroup) Handle(method, path string, handle XHandle) error {
+	return g.reg.handle(g.scope, method, g.prefix+path, Wrap(handle, g.middlewares...))
+}
+
+// HandleNamed is same as 'Handle' method, but the route has a name, see
+// 'HandleNamed' method of XRouter.
+func (g *XGroup) HandleNamed(name, method, path string, handle XHandle) error {
+	return g.reg.handleNamed(g.scope, name, method, g.prefix+path, Wrap(handle, g.middlewares...))
+}
+
+// Any registers a new request handle with the given path relative to the
+// prefix of the XGroup for all standard methods, see 'Any' method of XRouter.
+func (g *XGroup) Any(path string, handle XHandle) error {
+	return g.reg.any(g.scope, g.prefix+path, Wrap(handle, g.middlewares...))
+}
+
+// Remove unregisters an existing request handle with the given method and
+// the path relative to the prefix of the XGroup.
+func (g *XGroup) Remove(method, path string) error {
+	return g.reg.remove(g.scope, method, g.prefix+path)
+}
@@ -0,0 +1,85 @@
+// group_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+package xrouter
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Generate a middleware which appends its name to the response.
+func generateMiddleware(name string) Middleware {
+	return func(handle XHandle) XHandle {
+		return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+			w.Write([]byte(name + ">"))
+			handle(w, r, xps)
+		}
+	}
+}
+
+func serve(xr *XRouter, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	xr.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestGroup(t *testing.T) {
+	xr := New(&XConfig{})
+	handle := func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		w.Write([]byte("handle:" + xps.String()))
+	}
+
+	api := xr.Group("/api/", generateMiddleware("a"))
+	xassert.Equal(t, api.Prefix(), "/api")
+	v1 := api.Group("/v1", generateMiddleware("b"), generateMiddleware("c"))
+	xassert.Equal(t, v1.Prefix(), "/api/v1")
+
+	xassert.IsNil(t, api.GET("/ping", handle))
+	xassert.IsNil(t, v1.GET("/user/:name", handle))
+	xassert.IsNil(t, v1.POST("/user/:name", handle))
+	xassert.IsNil(t, xr.GET("/user/:name", handle))
+
+	// The route has been registered through the other group.
+	xassert.NotNil(t, xr.GET("/api/ping", handle))
+	xassert.NotNil(t, api.GET("/v1/user/:name", handle))
+	// The path doesn't begin with '/'.
+	xassert.NotNil(t, xr.Group("api").GET("/ping", handle))
+
+	for _, c := range []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/api/ping", 200, "a>handle:"},
+		{"GET", "/api/v1/user/blinklv", 200, "a>b>c>handle:name=blinklv"},
+		{"POST", "/api/v1/user/blinklv", 200, "a>b>c>handle:name=blinklv"},
+		{"GET", "/user/blinklv", 200, "handle:name=blinklv"},
+		{"GET", "/ping", 404, ""},
+	} {
+		w := serve(xr, c.method, c.path)
+		xassert.Equal(t, w.Code, c.code)
+		if c.code == 200 {
+			xassert.Equal(t, w.Body.String(), c.body)
+		}
+	}
+
+	// The grouped routes can be removed by both the group and the router.
+	xassert.IsNil(t, v1.Remove("GET", "/user/:name"))
+	xassert.IsNil(t, xr.Remove("POST", "/api/v1/user/:name"))
+	xassert.NotNil(t, v1.Remove("GET", "/user/:name"))
+	xassert.IsNil(t, api.Remove("GET", "/ping"))
+	xassert.Equal(t, serve(xr, "GET", "/api/v1/user/blinklv").Code, 404)
+	xassert.Equal(t, serve(xr, "POST", "/api/v1/user/blinklv").Code, 404)
+	xassert.Equal(t, serve(xr, "GET", "/api/ping").Code, 404)
+	xassert.Equal(t, serve(xr, "GET", "/user/blinklv").Code, 200)
+
+	// The route can be registered again after removing it.
+	xassert.IsNil(t, v1.GET("/user/:name", handle))
+	xassert.Equal(t, serve(xr, "GET", "/api/v1/user/blinklv").Code, 200)
+}
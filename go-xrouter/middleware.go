@@ -0,0 +1,149 @@
+// middleware.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xrouter
+
+import (
+	"github.com/X-Plan/xgo/go-xlog"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps an XHandle and returns a new one, it's used to share the
+// common logic (such as authentication and logging) between handlers. The
+// 'RequestID' function is a Middleware.
+type Middleware func(XHandle) XHandle
+
+// Wrap wraps an XHandle by the middlewares, the first middleware is the
+// outermost one. It's used to install middlewares for a single route:
+//
+//	xr.GET("/admin", xrouter.Wrap(Admin, Auth, xrouter.Timing(observe)))
+func Wrap(handle XHandle, middlewares ...Middleware) XHandle {
+	if handle == nil {
+		return nil
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handle = middlewares[i](handle)
+	}
+	return handle
+}
+
+// responseWriter records the status code and the size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+	return n, err
+}
+
+// Flush implements the http.Flusher interface if the underlying ResponseWriter
+// implements it.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter, it's used by the
+// http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// The status code is 200 if the handler doesn't write anything.
+func (rw *responseWriter) code() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// AccessLog returns a Middleware which writes a record to the XLogger for
+// each request after it has been handled. The record includes the method,
+// the URI, the status code, the size of the response body, the elapsed time,
+// the remote address and the fields carried by the context of the request
+// (such as the request ID, so 'RequestID' should be installed before it).
+// The level of the record is ERROR if the status code is 5xx, otherwise it's
+// INFO.
+//
+//	xr.Use(xrouter.RequestID, xrouter.AccessLog(xl), xrouter.Recovery(nil))
+//	// [2018-08-24 10:00:00][tag][INFO]:access request_id=9b2c0e1d method=GET uri=/user/blinklv status=200 size=7 elapsed=1.2ms remote=127.0.0.1:52100
+func AccessLog(xl *xlog.XLogger) Middleware {
+	return func(handle XHandle) XHandle {
+		return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+			start, rw := time.Now(), &responseWriter{ResponseWriter: w}
+			handle(rw, r, xps)
+
+			cxl := xl.WithContext(r.Context())
+			log := cxl.Infow
+			if rw.code() >= 500 {
+				log = cxl.Errorw
+			}
+			log("access",
+				"method", r.Method,
+				"uri", r.RequestURI,
+				"status", rw.code(),
+				"size", rw.size,
+				"elapsed", time.Since(start),
+				"remote", r.RemoteAddr,
+			)
+		}
+	}
+}
+
+// Recovery returns a Middleware which recovers the panics of handlers. The
+// 'panicHandler' parameter has the same semantics as the 'PanicHandler' field
+// of XConfig, if it's nil, the response is 500 (Internal Server Error). It's
+// useful when only some routes need to be protected, or the panics should be
+// handled inside the other middlewares (such as 'AccessLog').
+func Recovery(panicHandler func(http.ResponseWriter, *http.Request, interface{})) Middleware {
+	if panicHandler == nil {
+		panicHandler = func(w http.ResponseWriter, r *http.Request, _ interface{}) {
+			http.Error(w, http.StatusText(500), 500)
+		}
+	}
+
+	return func(handle XHandle) XHandle {
+		return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+			defer func() {
+				if x := recover(); x != nil {
+					panicHandler(w, r, x)
+				}
+			}()
+			handle(w, r, xps)
+		}
+	}
+}
+
+// Timing returns a Middleware which measures the elapsed time of handling each
+// request, the result and the status code of the response are passed to the
+// 'observe' function, it's useful to collect metrics.
+func Timing(observe func(r *http.Request, status int, elapsed time.Duration)) Middleware {
+	return func(handle XHandle) XHandle {
+		return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+			start, rw := time.Now(), &responseWriter{ResponseWriter: w}
+			handle(rw, r, xps)
+			observe(r, rw.code(), time.Since(start))
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// middleware_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+package xrouter
+
+import (
+	"fmt"
+	"github.com/X-Plan/xgo/go-xassert"
+	"github.com/X-Plan/xgo/go-xlog"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUse(t *testing.T) {
+	xr := New(&XConfig{HandleMethodNotAllowed: true})
+	handle := func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		w.Write([]byte("handle"))
+	}
+
+	// The global middlewares also wrap the routes registered before.
+	xassert.IsNil(t, xr.GET("/foo", Wrap(handle, generateMiddleware("c"))))
+	xr.Use(generateMiddleware("a"))
+	xr.Use(generateMiddleware("b"))
+	xassert.IsNil(t, xr.Group("/api", generateMiddleware("d")).GET("/bar", handle))
+
+	xassert.Equal(t, serve(xr, "GET", "/foo").Body.String(), "a>b>c>handle")
+	xassert.Equal(t, serve(xr, "GET", "/api/bar").Body.String(), "a>b>d>handle")
+
+	// The NotFound and MethodNotAllowed handlers aren't wrapped.
+	w := serve(xr, "GET", "/none")
+	xassert.Equal(t, w.Code, 404)
+	xassert.IsFalse(t, strings.HasPrefix(w.Body.String(), "a>"))
+	w = serve(xr, "POST", "/foo")
+	xassert.Equal(t, w.Code, 405)
+	xassert.IsFalse(t, strings.HasPrefix(w.Body.String(), "a>"))
+
+	xassert.IsNil(t, Wrap(nil, generateMiddleware("a")))
+}
+
+func TestRecovery(t *testing.T) {
+	xr := New(&XConfig{})
+	xassert.IsNil(t, xr.GET("/default", Wrap(func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		panic("oops")
+	}, Recovery(nil))))
+	xassert.IsNil(t, xr.GET("/custom", Wrap(func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		panic("oops")
+	}, Recovery(func(w http.ResponseWriter, r *http.Request, x interface{}) {
+		w.WriteHeader(503)
+		fmt.Fprintf(w, "%s", x)
+	}))))
+
+	w := serve(xr, "GET", "/default")
+	xassert.Equal(t, w.Code, 500)
+	w = serve(xr, "GET", "/custom")
+	xassert.Equal(t, w.Code, 503)
+	xassert.Equal(t, w.Body.String(), "oops")
+}
+
+func TestTiming(t *testing.T) {
+	var (
+		status  int
+		elapsed time.Duration
+		xr      = New(&XConfig{})
+	)
+
+	xr.Use(Timing(func(r *http.Request, s int, d time.Duration) {
+		status, elapsed = s, d
+	}))
+	xassert.IsNil(t, xr.GET("/sleep", func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(201)
+		w.WriteHeader(202) // Only the first one is recorded.
+	}))
+	xassert.IsNil(t, xr.GET("/empty", func(w http.ResponseWriter, r *http.Request, xps XParams) {}))
+
+	xassert.Equal(t, serve(xr, "GET", "/sleep").Code, 201)
+	xassert.Equal(t, status, 201)
+	xassert.IsTrue(t, elapsed >= 10*time.Millisecond)
+
+	serve(xr, "GET", "/empty")
+	xassert.Equal(t, status, 200)
+}
+
+func TestAccessLog(t *testing.T) {
+	dir := "/tmp/xrouter_access_log"
+	defer os.RemoveAll(dir)
+
+	xl, err := xlog.New(&xlog.XConfig{Dir: dir, Level: xlog.INFO})
+	xassert.IsNil(t, err)
+
+	xr := New(&XConfig{})
+	xr.Use(RequestID, AccessLog(xl), Recovery(nil))
+	xassert.IsNil(t, xr.GET("/user/:name", func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		w.Write([]byte(xps.Get("name")))
+	}))
+	xassert.IsNil(t, xr.GET("/panic", func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		panic("oops")
+	}))
+
+	serve(xr, "GET", "/user/blinklv?id=10")
+	serve(xr, "GET", "/panic")
+	xassert.IsNil(t, xl.Close())
+
+	r, err := xlog.NewFileReader(dir, time.Time{}, time.Time{})
+	xassert.IsNil(t, err)
+	data, err := ioutil.ReadAll(r)
+	xassert.IsNil(t, err)
+	xassert.IsNil(t, r.Close())
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	xassert.Equal(t, len(lines), 2)
+	xassert.Match(t, lines[0], `\[INFO\].*access request_id=\w{16} method=GET uri="/user/blinklv\?id=10" status=200 size=7 elapsed=\S+ remote=\S+$`)
+	xassert.Match(t, lines[1], `\[ERROR\].*access request_id=\w{16} method=GET uri=/panic status=500 `)
+}
@@ -0,0 +1,81 @@
+// param.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xrouter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The builtin constraints of param wildcards, the other constraints are
+// regular expressions.
+var builtinConstraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// Parse a param wildcard segment (without the trailing slash), such as ':id'
+// or ':id<int>'. The constraint is compiled to a regular expression matching
+// the whole value, it's nil if the segment has no constraint.
+func parseParam(seg, full string) (*regexp.Regexp, error) {
+	name, expr, lt := seg[1:], "", strings.IndexByte(seg, '<')
+	if lt != -1 {
+		if seg[len(seg)-1] != '>' {
+			return nil, fmt.Errorf("'%s' in path '%s': constraint must be at the end of the path segment", seg, full)
+		}
+		name, expr = seg[1:lt], seg[lt+1:len(seg)-1]
+	}
+
+	if strings.ContainsAny(name, ":*") {
+		return nil, fmt.Errorf("'%s' in path '%s': only one wildcard per path segment is allowed", seg, full)
+	}
+
+	if len(name) == 0 {
+		return nil, fmt.Errorf("'%s' in path '%s': param wildcard can't be empty", seg, full)
+	}
+
+	if lt == -1 {
+		return nil, nil
+	}
+
+	if len(expr) == 0 {
+		return nil, fmt.Errorf("'%s' in path '%s': constraint can't be empty", seg, full)
+	}
+
+	if builtin, ok := builtinConstraints[expr]; ok {
+		expr = builtin
+	}
+
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("'%s' in path '%s': constraint is invalid (%s)", seg, full, err)
+	}
+	return re, nil
+}
+
+// Get the head of a param wildcard path, it's the part before the first '/',
+// such as ':id<int>' of ':id<int>/foo'. Two param wildcards with the same
+// head share the same node.
+func paramHead(path string) string {
+	if i := strings.IndexByte(path, '/'); i != -1 {
+		return path[:i]
+	}
+	return path
+}
+
+// Get the key of a param wildcard node, such as 'id' of ':id<int>/'.
+func paramKey(path string) string {
+	key := paramHead(path)[1:]
+	if i := strings.IndexByte(key, '<'); i != -1 {
+		key = key[:i]
+	}
+	return key
+}
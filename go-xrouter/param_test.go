@@ -0,0 +1,162 @@
+// param_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+package xrouter
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"net/http"
+	"testing"
+)
+
+func TestParseParam(t *testing.T) {
+	for _, c := range []struct {
+		seg   string
+		ok    bool
+		value string // A value satisfies the constraint.
+	}{
+		{":id", true, ""},
+		{":id<int>", true, "-12"},
+		{":id<uint>", true, "12"},
+		{":name<alpha>", true, "blinklv"},
+		{":uuid<uuid>", true, "9b2c0e1d-5b7e-4e1f-8a7c-6f3d2e1c0b9a"},
+		{":slug<[a-z-]+>", true, "hello-world"},
+		{":n<[0-9]*>", true, ""},
+		{":", false, ""},
+		{":<int>", false, ""},
+		{":id<>", false, ""},
+		{":id<int", false, ""},
+		{":id<int>x", false, ""},
+		{":id<[0-9>", false, ""},
+		{":a:b", false, ""},
+		{":a*b<int>", false, ""},
+	} {
+		re, err := parseParam(c.seg, c.seg)
+		xassert.Equal(t, err == nil, c.ok)
+		if err == nil && re != nil {
+			xassert.IsTrue(t, re.MatchString(c.value))
+			xassert.IsFalse(t, re.MatchString(c.value+"/"))
+		}
+	}
+
+	xassert.Equal(t, paramKey(":id<int>/"), "id")
+	xassert.Equal(t, paramKey(":id/"), "id")
+	xassert.Equal(t, paramKey(":id"), "id")
+	xassert.Equal(t, paramHead(":id<int>/foo"), ":id<int>")
+}
+
+func TestConstraint(t *testing.T) {
+	xr := New(&XConfig{RedirectTrailingSlash: true})
+	generate := func(tag string) XHandle {
+		return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+			w.Write([]byte(tag + ":" + xps.String()))
+		}
+	}
+
+	for _, path := range []string{
+		"/item/:id<int>",
+		"/item/:id<int>/posts",
+		"/item/:uuid<uuid>",
+		"/item/:slug<[a-z-]+>/",
+		"/item/:any/detail",
+		"/num/:id<int>/:n<[0-9]*>/x",
+	} {
+		xassert.IsNil(t, xr.GET(path, generate(path)))
+	}
+
+	xassert.IsNil(t, xr.current().tbl.getTree("GET", false).load().check())
+
+	xassert.NotNil(t, xr.GET("/item/:id<int>", generate("")))    // has been registered
+	xassert.NotNil(t, xr.GET("/item/:other", generate("")))      // only one unconstrained param
+	xassert.NotNil(t, xr.GET("/item/static", generate("")))      // conflict with the param wildcards
+	xassert.NotNil(t, xr.GET("/item/*all", generate("")))        // conflict with the param wildcards
+	xassert.NotNil(t, xr.GET("/item/:id<[0-9>", generate("")))   // invalid constraint
+	xassert.NotNil(t, xr.GET("/item/:id<int>x/y", generate(""))) // constraint isn't at the end
+	xassert.NotNil(t, xr.GET("/other/:id<int", generate("")))    // constraint isn't closed
+
+	for _, c := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/item/123", 200, "/item/:id<int>:id=123"},
+		{"/item/-123/posts", 200, "/item/:id<int>/posts:id=-123"},
+		{"/item/9b2c0e1d-5b7e-4e1f-8a7c-6f3d2e1c0b9a", 200, "/item/:uuid<uuid>:uuid=9b2c0e1d-5b7e-4e1f-8a7c-6f3d2e1c0b9a"},
+		{"/item/hello-world/", 200, "/item/:slug<[a-z-]+>/:slug=hello-world"},
+		{"/item/hello-world", 301, ""}, // redirect to '/item/hello-world/'
+		{"/item/123/", 301, ""},        // redirect to '/item/123'
+		// Fall back to the unconstrained one.
+		{"/item/123/detail", 200, "/item/:any/detail:any=123"},
+		{"/item/HELLO/detail", 200, "/item/:any/detail:any=HELLO"},
+		{"/num/123/456/x", 200, "/num/:id<int>/:n<[0-9]*>/x:id=123,n=456"},
+		{"/item/HELLO", 404, ""},
+		{"/num/123/abc/x", 404, ""},
+		{"/item/123/comments", 404, ""},
+	} {
+		w := serve(xr, "GET", c.path)
+		xassert.Equal(t, w.Code, c.code, c.path)
+		if c.code == 200 {
+			xassert.Equal(t, w.Body.String(), c.body)
+		}
+	}
+
+	// Remove the constrained routes, the others are still available.
+	xassert.NotNil(t, xr.Remove("GET", "/item/:id"))
+	xassert.IsNil(t, xr.Remove("GET", "/item/:id<int>"))
+	xassert.IsNil(t, xr.Remove("GET", "/item/:slug<[a-z-]+>/"))
+	xassert.IsNil(t, xr.current().tbl.getTree("GET", false).load().check())
+	xassert.Equal(t, serve(xr, "GET", "/item/123").Code, 404)
+	xassert.Equal(t, serve(xr, "GET", "/item/hello-world/").Code, 404)
+	xassert.Equal(t, serve(xr, "GET", "/item/123/posts").Code, 200)
+	xassert.Equal(t, serve(xr, "GET", "/item/123/detail").Code, 200)
+	xassert.Equal(t, xr.Routes(), []Route{
+		{Method: "GET", Path: "/item/:any/detail"},
+		{Method: "GET", Path: "/item/:id<int>/posts"},
+		{Method: "GET", Path: "/item/:uuid<uuid>"},
+		{Method: "GET", Path: "/num/:id<int>/:n<[0-9]*>/x"},
+	})
+
+	xassert.IsNil(t, xr.Remove("GET", "/item/:any/detail"))
+	xassert.IsNil(t, xr.GET("/item/:other", generate("/item/:other")))
+	xassert.Equal(t, serve(xr, "GET", "/item/foo").Body.String(), "/item/:other:other=foo")
+}
+
+func TestTypedParams(t *testing.T) {
+	xps := XParams{{"id", "-12"}, {"count", "18446744073709551615"}, {"name", "blinklv"}}
+
+	i, err := xps.Int("id")
+	xassert.IsNil(t, err)
+	xassert.Equal(t, i, -12)
+
+	i64, err := xps.Int64("id")
+	xassert.IsNil(t, err)
+	xassert.Equal(t, i64, int64(-12))
+
+	u64, err := xps.Uint64("count")
+	xassert.IsNil(t, err)
+	xassert.Equal(t, u64, uint64(18446744073709551615))
+
+	_, err = xps.Int("name")
+	xassert.NotNil(t, err)
+	_, err = xps.Int("none")
+	xassert.NotNil(t, err)
+	_, err = xps.Uint64("id")
+	xassert.NotNil(t, err)
+}
+
+func TestURLConstraint(t *testing.T) {
+	xr := New(&XConfig{})
+	xassert.IsNil(t, xr.HandleNamed("post", "GET", "/user/:id<int>/posts/:slug<[a-z-]+>", generateHandle("GET", "")))
+
+	url, err := xr.URL("post", XParams{{"id", "12"}, {"slug", "hello-world"}})
+	xassert.IsNil(t, err)
+	xassert.Equal(t, url, "/user/12/posts/hello-world")
+
+	_, err = xr.URL("post", XParams{{"id", "blinklv"}, {"slug", "hello-world"}})
+	xassert.NotNil(t, err)
+	_, err = xr.URL("post", XParams{{"id", "12"}, {"slug", "Hello"}})
+	xassert.NotNil(t, err)
+}
@@ -0,0 +1,286 @@
+// route.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xrouter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Route is a registered route of an XRouter.
+type Route struct {
+	Method string
+	Path   string
+	Name   string // It's empty if the route isn't named.
+	Scope  Scope  // It's the zero value if the route is a default route.
+}
+
+// The string format of a Route is 'METHOD path', the scope is appended if
+// it's not the zero value.
+func (r Route) String() string {
+	if r.Scope != (Scope{}) {
+		return r.Method + " " + r.Path + " " + r.Scope.String()
+	}
+	return r.Method + " " + r.Path
+}
+
+// Routes returns all registered routes (including the scoped ones), they're
+// sorted by the scope, the method and the path, and the default routes come
+// first. Because the routes can be added and removed at runtime, the result
+// is only a snapshot.
+func (xr *XRouter) Routes() []Route {
+	return xr.current().routes()
+}
+
+func (rs *routeSet) routes() []Route {
+	var (
+		routes []Route
+		names  = make(map[Route]string)
+	)
+
+	rs.nmtx.RLock()
+	for name, nr := range rs.names {
+		names[Route{Method: nr.method, Path: nr.path, Scope: nr.scope}] = name
+	}
+	rs.nmtx.RUnlock()
+
+	rs.eachTable(func(s Scope, tb *table) {
+		for method, t := range tb.load() {
+			t.walk(func(path string, _ XHandle) {
+				route := Route{Method: method, Path: path, Scope: s}
+				route.Name = names[route]
+				routes = append(routes, route)
+			})
+		}
+	})
+
+	sort.Slice(routes, func(i, j int) bool {
+		if si, sj := routes[i].Scope.String(), routes[j].Scope.String(); si != sj {
+			return si < sj
+		}
+		if routes[i].Method != routes[j].Method {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes
+}
+
+// Call the 'fn' function for the default table and the tables of scopes in
+// the order of creation.
+func (rs *routeSet) eachTable(fn func(Scope, *table)) {
+	fn(Scope{}, rs.tbl)
+	for _, sc := range rs.loadScopes() {
+		fn(sc.Scope, sc.tbl)
+	}
+}
+
+// Lookup returns the handle and the parameters matching the given method and
+// path without serving the request, the handle is nil if there is no match.
+// Only the default routes are searched. The returned handle isn't wrapped by
+// the global middlewares (see 'Use' method), and the trailing slash redirection
+// and path fixing aren't applied.
+func (xr *XRouter) Lookup(method, path string) (XHandle, XParams) {
+	t := xr.current().tbl.getTree(strings.ToUpper(method), false)
+	if t == nil {
+		return nil, nil
+	}
+
+	handle, xps, _ := t.get(path, false)
+	return handle, xps
+}
+
+// DebugHandler returns an XHandle rendering the route table (the method, the
+// path, the name and the scope of each route) in plain text, it's useful to
+// check the routes added and removed at runtime. If the 'tree' query parameter
+// isn't empty, the internal trees of methods are rendered too. It's not
+// registered by default, because the routes may be sensitive.
+//
+//	xr.GET("/debug/routes", xr.DebugHandler())
+func (xr *XRouter) DebugHandler() XHandle {
+	return func(w http.ResponseWriter, r *http.Request, _ XParams) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		// The route table and the trees are rendered from the same snapshot.
+		rs := xr.current()
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		for _, route := range rs.routes() {
+			fmt.Fprintf(tw, "%s\t%s", route.Method, route.Path)
+			if scope := route.Scope.String(); scope != "" {
+				fmt.Fprintf(tw, "\t%s\t%s", route.Name, scope)
+			} else if route.Name != "" {
+				fmt.Fprintf(tw, "\t%s", route.Name)
+			}
+			fmt.Fprintln(tw)
+		}
+		tw.Flush()
+
+		if r.URL.Query().Get("tree") == "" {
+			return
+		}
+
+		rs.eachTable(func(s Scope, tb *table) {
+			for _, method := range tb.methods() {
+				if t := tb.getTree(method, false); t != nil {
+					fmt.Fprintf(w, "\n# %s\n", strings.TrimSpace(method+" "+s.String()))
+					t.fprint(w)
+				}
+			}
+		})
+	}
+}
+
+// A named route, its pattern is split into segments.
+type namedRoute struct {
+	method   string
+	path     string
+	scope    Scope
+	segments []segment
+}
+
+// A segment of a route pattern.
+type segment struct {
+	nt   nodeType
+	text string         // The static text, or the name of a wildcard.
+	re   *regexp.Regexp // The constraint of a param wildcard.
+}
+
+// Split a route pattern into segments. The pattern is parsed by constructing
+// a temporary node, so the rules are exactly same as the ones of 'Handle'
+// method, such as a catch-all wildcard must be at the end of the pattern.
+func parsePattern(path string) ([]segment, error) {
+	if len(path) == 0 || path[0] != '/' {
+		return nil, fmt.Errorf("path ('%s') must begin with '/'", path)
+	}
+
+	n := &node{}
+	if err := n.construct(path, path, nil); err != nil {
+		return nil, err
+	}
+
+	// The nodes constructed from a single path is a chain.
+	var segments []segment
+	for {
+		switch n.nt {
+		case static:
+			segments = append(segments, segment{static, n.path, nil})
+		case param:
+			segments = append(segments, segment{param, paramKey(n.path), n.re})
+			// The path of a param node includes the trailing slash.
+			if n.path[len(n.path)-1] == '/' {
+				segments = append(segments, segment{static, "/", nil})
+			}
+		case all:
+			segments = append(segments, segment{all, n.path[1:], nil})
+		}
+
+		if len(n.children) == 0 {
+			break
+		}
+		n = n.children[0]
+	}
+	return segments, nil
+}
+
+// HandleNamed is same as 'Handle' method, but the route has a name, so its
+// URL can be built by 'URL' method. A name can only be used by one route,
+// and it's released when the route is removed.
+func (xr *XRouter) HandleNamed(name, method, path string, handle XHandle) error {
+	return xr.handleNamed(Scope{}, name, method, path, handle)
+}
+
+func (xr *XRouter) handleNamed(s Scope, name, method, path string, handle XHandle) error {
+	return xr.modify(func(rs *routeSet) error { return rs.handleNamed(s, name, method, path, handle) })
+}
+
+func (rs *routeSet) handleNamed(s Scope, name, method, path string, handle XHandle) error {
+	if name == "" {
+		return fmt.Errorf("route name can't be empty")
+	}
+
+	segments, err := parsePattern(path)
+	if err != nil {
+		return err
+	}
+
+	rs.nmtx.Lock()
+	defer rs.nmtx.Unlock()
+
+	if _, ok := rs.names[name]; ok {
+		return fmt.Errorf("route name (%s) has already been used", name)
+	}
+
+	if err = rs.handle(s, method, path, handle); err != nil {
+		return err
+	}
+
+	rs.names[name] = &namedRoute{method: strings.ToUpper(method), path: path, scope: s, segments: segments}
+	return nil
+}
+
+// Release the name of a removed route.
+func (rs *routeSet) removeName(s Scope, method, path string) {
+	rs.nmtx.Lock()
+	for name, nr := range rs.names {
+		if nr.method == method && nr.path == path && nr.scope == s {
+			delete(rs.names, name)
+			break
+		}
+	}
+	rs.nmtx.Unlock()
+}
+
+// URL builds the path of a named route by replacing the wildcards in its
+// pattern with the values of the parameters, the values are escaped. All
+// param wildcards must be supplied with non-empty values which don't contain
+// '/' and satisfy the constraints, and the catch-all wildcard can be omitted.
+// The extra parameters are ignored.
+//
+//	xr.HandleNamed("file", "GET", "/user/:name/files/*path", handle)
+//	xr.URL("file", xrouter.XParams{{"name", "blinklv"}, {"path", "a/b c"}})
+//	// /user/blinklv/files/a/b%20c
+func (xr *XRouter) URL(name string, xps XParams) (string, error) {
+	rs := xr.current()
+	rs.nmtx.RLock()
+	nr := rs.names[name]
+	rs.nmtx.RUnlock()
+
+	if nr == nil {
+		return "", fmt.Errorf("route name (%s) hasn't been registered", name)
+	}
+
+	var b strings.Builder
+	for _, seg := range nr.segments {
+		if seg.nt == static {
+			b.WriteString(seg.text)
+			continue
+		}
+
+		value, ok := xps.lookup(seg.text)
+		switch {
+		case seg.nt == all:
+			parts := strings.Split(value, "/")
+			for i := range parts {
+				parts[i] = url.PathEscape(parts[i])
+			}
+			b.WriteString(strings.Join(parts, "/"))
+		case !ok:
+			return "", fmt.Errorf("parameter (%s) of route (%s) is missing", seg.text, name)
+		case value == "" || strings.Contains(value, "/") || seg.re != nil && !seg.re.MatchString(value):
+			return "", fmt.Errorf("parameter (%s) of route (%s) is invalid", seg.text, name)
+		default:
+			b.WriteString(url.PathEscape(value))
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,149 @@
+// route_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+package xrouter
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	xr := New(&XConfig{})
+	xassert.Equal(t, len(xr.Routes()), 0)
+
+	paths := []pathType{
+		{[]string{"GET", "POST"}, "/authorizations", nil},
+		{[]string{"GET", "DELETE"}, "/authorizations/:id", nil},
+		{[]string{"GET"}, "/users/:user/events", nil},
+		{[]string{"GET"}, "/users/:user/events/public", nil},
+		{[]string{"PROPFIND"}, "/files/*path", nil},
+		{[]string{"GET"}, "/", nil},
+	}
+	xassert.IsNil(t, configureXRouter(xr, paths, generateHandle))
+
+	xassert.Equal(t, xr.Routes(), []Route{
+		{Method: "DELETE", Path: "/authorizations/:id"},
+		{Method: "GET", Path: "/"},
+		{Method: "GET", Path: "/authorizations"},
+		{Method: "GET", Path: "/authorizations/:id"},
+		{Method: "GET", Path: "/users/:user/events"},
+		{Method: "GET", Path: "/users/:user/events/public"},
+		{Method: "POST", Path: "/authorizations"},
+		{Method: "PROPFIND", Path: "/files/*path"},
+	})
+
+	// The routes removed at runtime are excluded.
+	xassert.IsNil(t, xr.Remove("GET", "/users/:user/events"))
+	xassert.IsNil(t, xr.Remove("DELETE", "/authorizations/:id"))
+	routes := xr.Routes()
+	xassert.Equal(t, len(routes), 6)
+	xassert.Equal(t, routes[0].String(), "GET /")
+	xassert.Equal(t, routes[3].String(), "GET /users/:user/events/public")
+}
+
+func TestLookup(t *testing.T) {
+	xr := New(&XConfig{RedirectTrailingSlash: true})
+	handle := func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		w.Write([]byte("handle"))
+	}
+	xassert.IsNil(t, xr.GET("/user/:name/", handle))
+	xassert.IsNil(t, xr.Handle("PROPFIND", "/files/*path", handle))
+
+	h, xps := xr.Lookup("GET", "/user/blinklv/")
+	xassert.NotNil(t, h)
+	xassert.IsTrue(t, xps.Equal(XParams{{"name", "blinklv"}}))
+
+	h, xps = xr.Lookup("propfind", "/files/a/b")
+	xassert.NotNil(t, h)
+	xassert.Equal(t, xps.Get("path"), "a/b")
+
+	// The trailing slash redirection isn't applied.
+	h, _ = xr.Lookup("GET", "/user/blinklv")
+	xassert.IsNil(t, h)
+	h, _ = xr.Lookup("POST", "/user/blinklv/")
+	xassert.IsNil(t, h)
+}
+
+func TestDebugHandler(t *testing.T) {
+	xr := New(&XConfig{})
+	xassert.IsNil(t, xr.GET("/debug/routes", xr.DebugHandler()))
+	xassert.IsNil(t, xr.POST("/user/:name", generateHandle("POST", "/user/:name")))
+
+	w := serve(xr, "GET", "/debug/routes")
+	xassert.Equal(t, w.Code, 200)
+	xassert.Equal(t, w.Body.String(), "GET   /debug/routes\nPOST  /user/:name\n")
+
+	w = serve(xr, "GET", "/debug/routes?tree=1")
+	lines := strings.Split(w.Body.String(), "\n")
+	xassert.Equal(t, lines[3], "# GET")
+	xassert.Equal(t, lines[4], "1:[/] /debug/routes (static:0:true)")
+	xassert.Equal(t, lines[6], "# POST")
+	xassert.Equal(t, lines[7], "1:[/] /user/ (static:1:false)")
+	xassert.Equal(t, lines[8], "  1:[ ] :name (param:1:true)")
+}
+
+func TestURL(t *testing.T) {
+	xr := New(&XConfig{})
+	handle := generateHandle("GET", "")
+
+	xassert.IsNil(t, xr.HandleNamed("index", "GET", "/", handle))
+	xassert.IsNil(t, xr.HandleNamed("user", "get", "/user/:name/", handle))
+	xassert.IsNil(t, xr.HandleNamed("repo", "POST", "/repos/:owner/:repo/events", handle))
+	xassert.IsNil(t, xr.Group("/api").HandleNamed("file", "GET", "/user/:name/files/*path", handle))
+
+	xassert.NotNil(t, xr.HandleNamed("", "GET", "/foo", handle))
+	xassert.NotNil(t, xr.HandleNamed("user", "GET", "/foo", handle))          // name has been used
+	xassert.NotNil(t, xr.HandleNamed("foo", "GET", "/user/:name/", handle))   // path has been registered
+	xassert.NotNil(t, xr.HandleNamed("foo", "GET", "/files/*path/a", handle)) // catch-all isn't at the end
+	xassert.NotNil(t, xr.HandleNamed("foo", "GET", "/files/:a:b", handle))    // two wildcards in a segment
+	xassert.NotNil(t, xr.HandleNamed("foo", "GET", "files", handle))          // path doesn't begin with '/'
+	h, _ := xr.Lookup("GET", "/foo")
+	xassert.IsNil(t, h)
+
+	for _, c := range []struct {
+		name string
+		xps  XParams
+		url  string
+		ok   bool
+	}{
+		{"index", nil, "/", true},
+		{"user", XParams{{"name", "blinklv"}}, "/user/blinklv/", true},
+		{"user", XParams{{"name", "a b?"}, {"extra", "x"}}, "/user/a%20b%3F/", true},
+		{"user", nil, "", false},
+		{"user", XParams{{"name", ""}}, "", false},
+		{"user", XParams{{"name", "a/b"}}, "", false},
+		{"repo", XParams{{"repo", "xgo"}, {"owner", "X-Plan"}}, "/repos/X-Plan/xgo/events", true},
+		{"repo", XParams{{"owner", "X-Plan"}}, "", false},
+		{"file", XParams{{"name", "blinklv"}, {"path", "a/b c"}}, "/api/user/blinklv/files/a/b%20c", true},
+		{"file", XParams{{"name", "blinklv"}}, "/api/user/blinklv/files/", true},
+		{"none", nil, "", false},
+	} {
+		url, err := xr.URL(c.name, c.xps)
+		xassert.Equal(t, err == nil, c.ok)
+		xassert.Equal(t, url, c.url)
+	}
+
+	// The URL matches the route.
+	url, _ := xr.URL("file", XParams{{"name", "blinklv"}, {"path", "a/b"}})
+	h, xps := xr.Lookup("GET", url)
+	xassert.NotNil(t, h)
+	xassert.IsTrue(t, xps.Equal(XParams{{"name", "blinklv"}, {"path", "a/b"}}))
+
+	routes := xr.Routes()
+	xassert.Equal(t, routes[len(routes)-1], Route{Method: "POST", Path: "/repos/:owner/:repo/events", Name: "repo"})
+
+	// The name is released after removing the route.
+	xassert.IsNil(t, xr.Remove("GET", "/user/:name/"))
+	_, err := xr.URL("user", XParams{{"name", "blinklv"}})
+	xassert.NotNil(t, err)
+	xassert.IsNil(t, xr.HandleNamed("user", "GET", "/users/:name", handle))
+	url, err = xr.URL("user", XParams{{"name", "blinklv"}})
+	xassert.IsNil(t, err)
+	xassert.Equal(t, url, "/users/blinklv")
+}
@@ -0,0 +1,232 @@
+// scope.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xrouter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Scope describes the requests matched by a set of routes, the zero value
+// of a field matches any request, and the zero Scope represents the default
+// routes. A Scope is comparable, the routes registered with equal Scopes
+// share the same routing table.
+type Scope struct {
+	// The host pattern (case insensitive) matching the host of a request
+	// without the port. The labels of it are separated by '.', a label like
+	// ':name' matches a label of the host, and the first label can be like
+	// '*name', which matches one or more labels. The matched values are
+	// captured into the XParams, and they precede the path parameters:
+	//
+	//	Pattern: :tenant.example.com
+	//	 foo.example.com                      match: tenant="foo"
+	//	 a.foo.example.com                    no match
+	//
+	//	Pattern: *sub.example.com
+	//	 a.foo.example.com                    match: sub="a.foo"
+	//	 example.com                          no match
+	Host string
+
+	// The header of a request must have the given value, if the value is
+	// empty, the header only needs to be non-empty.
+	Header      string
+	HeaderValue string
+
+	// The query parameter of a request must have the given value, if the
+	// value is empty, the query parameter only needs to exist.
+	Query      string
+	QueryValue string
+}
+
+// The string format of a Scope is 'host=HOST header=KEY:VALUE query=KEY=VALUE',
+// the empty conditions are omitted.
+func (s Scope) String() string {
+	var parts []string
+	if s.Host != "" {
+		parts = append(parts, "host="+s.Host)
+	}
+	if s.Header != "" {
+		parts = append(parts, "header="+s.Header+":"+s.HeaderValue)
+	}
+	if s.Query != "" {
+		parts = append(parts, "query="+s.Query+"="+s.QueryValue)
+	}
+	return strings.Join(parts, " ")
+}
+
+// The routing table of a Scope.
+type scope struct {
+	Scope
+	host hostPattern
+	tbl  *table
+}
+
+// Scope returns an XGroup whose routes only match the requests in the scope.
+// The routes of scopes precede the default routes: the first scope (in the
+// order of creation) matching a request and having a route for its path (in
+// any method) is selected, then the request is dispatched by the routes of it,
+// the default routes and the routes of other scopes aren't considered. If no
+// scope is selected, the default routes are used, so a scope whose routes have
+// all been removed doesn't affect any request. The invalid Scope is reported
+// when registering routes.
+//
+//	api := xr.Scope(xrouter.Scope{Host: "api.example.com"})
+//	api.GET("/user/:name", GetUser)
+//	v2 := xr.Scope(xrouter.Scope{Header: "X-Api-Version", HeaderValue: "2"})
+//	v2.GET("/user/:name", GetUserV2)
+func (xr *XRouter) Scope(s Scope) *XGroup {
+	return &XGroup{reg: xr, scope: s}
+}
+
+// Host is a shortcut for Scope(Scope{Host: pattern}).
+func (xr *XRouter) Host(pattern string) *XGroup {
+	return xr.Scope(Scope{Host: pattern})
+}
+
+// Get the routing table of a Scope, if it doesn't exist and 'create' is true,
+// a new one will be created.
+func (rs *routeSet) table(s Scope, create bool) (*table, error) {
+	if s == (Scope{}) {
+		return rs.tbl, nil
+	}
+
+	if sc := rs.findScope(s); sc != nil {
+		return sc.tbl, nil
+	} else if !create {
+		return nil, nil
+	}
+
+	if (s.Header == "" && s.HeaderValue != "") || (s.Query == "" && s.QueryValue != "") {
+		return nil, fmt.Errorf("scope (%s) is invalid", s)
+	}
+
+	hp, err := parseHost(s.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.smtx.Lock()
+	defer rs.smtx.Unlock()
+	sc := rs.findScope(s)
+	if sc == nil {
+		sc = &scope{Scope: s, host: hp, tbl: newTable()}
+		// Copy on write, so the slice read by 'match' method won't be modified.
+		old := rs.loadScopes()
+		scopes := make([]*scope, 0, len(old)+1)
+		rs.scopes.Store(append(append(scopes, old...), sc))
+	}
+	return sc.tbl, nil
+}
+
+func (rs *routeSet) findScope(s Scope) *scope {
+	for _, sc := range rs.loadScopes() {
+		if sc.Scope == s {
+			return sc
+		}
+	}
+	return nil
+}
+
+// Select the routing table for a request, and return the values of the
+// wildcards in the host pattern. The 'tsr' parameter has the same meaning as
+// the 'enableTSR' parameter of 'get' method of tree.
+func (rs *routeSet) match(r *http.Request, path string, tsr bool) (*table, XParams) {
+	scopes := rs.loadScopes()
+	if len(scopes) == 0 {
+		return rs.tbl, nil
+	}
+
+	host := strings.ToLower(r.Host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	for _, sc := range scopes {
+		if xps, ok := sc.match(r, host); ok && sc.tbl.routable(path, tsr) {
+			return sc.tbl, xps
+		}
+	}
+	return rs.tbl, nil
+}
+
+func (sc *scope) match(r *http.Request, host string) (xps XParams, ok bool) {
+	if sc.Header != "" {
+		if value := r.Header.Get(sc.Header); value == "" || (sc.HeaderValue != "" && value != sc.HeaderValue) {
+			return nil, false
+		}
+	}
+
+	if sc.Query != "" {
+		values, exist := r.URL.Query()[sc.Query]
+		if !exist || (sc.QueryValue != "" && (len(values) == 0 || values[0] != sc.QueryValue)) {
+			return nil, false
+		}
+	}
+
+	if sc.host == nil {
+		return nil, true
+	}
+	return sc.host.match(host)
+}
+
+// The labels of a host pattern.
+type hostPattern []string
+
+// Parse a host pattern, the empty pattern matches any host.
+func parseHost(pattern string) (hostPattern, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	labels := strings.Split(strings.ToLower(pattern), ".")
+	for i, label := range labels {
+		switch {
+		case label == "":
+			return nil, fmt.Errorf("host pattern (%s) has an empty label", pattern)
+		case label[0] == '*' && i > 0:
+			return nil, fmt.Errorf("host pattern (%s): '%s' must be the first label", pattern, label)
+		case (label[0] == ':' || label[0] == '*') && len(label) == 1:
+			return nil, fmt.Errorf("host pattern (%s): wildcard can't be empty", pattern)
+		case strings.ContainsAny(label[1:], ":*") || strings.ContainsAny(label, "/[]"):
+			return nil, fmt.Errorf("host pattern (%s): '%s' is invalid", pattern, label)
+		}
+	}
+	return hostPattern(labels), nil
+}
+
+// Match a host (in lower case, without port) against the pattern.
+func (hp hostPattern) match(host string) (xps XParams, ok bool) {
+	labels := strings.Split(host, ".")
+	if hp[0][0] == '*' {
+		// The catch-all label matches one or more labels.
+		n := len(labels) - len(hp) + 1
+		if n < 1 {
+			return nil, false
+		}
+		xps = append(xps, XParam{Key: hp[0][1:], Value: strings.Join(labels[:n], ".")})
+		labels, hp = labels[n:], hp[1:]
+	}
+
+	if len(labels) != len(hp) {
+		return nil, false
+	}
+
+	for i, label := range hp {
+		if label[0] == ':' {
+			if labels[i] == "" {
+				return nil, false
+			}
+			xps = append(xps, XParam{Key: label[1:], Value: labels[i]})
+		} else if label != labels[i] {
+			return nil, false
+		}
+	}
+	return xps, true
+}
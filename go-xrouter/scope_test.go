@@ -0,0 +1,217 @@
+// scope_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+package xrouter
+
+import (
+	"github.com/X-Plan/xgo/go-xassert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveRequest(xr *XRouter, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	xr.ServeHTTP(w, r)
+	return w
+}
+
+func TestParseHost(t *testing.T) {
+	for _, pattern := range []string{"example.com", ":tenant.example.com", "*sub.example.com", "*sub.:tenant.example.com", "*all"} {
+		_, err := parseHost(pattern)
+		xassert.IsNil(t, err)
+	}
+
+	for _, pattern := range []string{"example..com", ".example.com", "a.*sub.com", ":.example.com", "*.example.com", "a:b.example.com", "x*y.com", "a/b.com"} {
+		_, err := parseHost(pattern)
+		xassert.NotNil(t, err)
+	}
+}
+
+func TestHostPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		ok      bool
+		xps     XParams
+	}{
+		{"example.com", "example.com", true, nil},
+		{"example.com", "www.example.com", false, nil},
+		{":tenant.example.com", "foo.example.com", true, XParams{{"tenant", "foo"}}},
+		{":tenant.example.com", "a.foo.example.com", false, nil},
+		{":tenant.example.com", "example.com", false, nil},
+		{"*sub.example.com", "a.foo.example.com", true, XParams{{"sub", "a.foo"}}},
+		{"*sub.example.com", "foo.example.com", true, XParams{{"sub", "foo"}}},
+		{"*sub.example.com", "example.com", false, nil},
+		{"*sub.:tenant.example.com", "a.b.foo.example.com", true, XParams{{"sub", "a.b"}, {"tenant", "foo"}}},
+	}
+
+	for _, test := range tests {
+		hp, err := parseHost(test.pattern)
+		xassert.IsNil(t, err)
+		xps, ok := hp.match(test.host)
+		xassert.Equal(t, ok, test.ok)
+		xassert.Equal(t, xps, test.xps)
+	}
+}
+
+func TestScope(t *testing.T) {
+	xr := New(&XConfig{HandleMethodNotAllowed: true})
+	handle := func(name string) XHandle {
+		return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+			w.Write([]byte(name + ":" + xps.String()))
+		}
+	}
+
+	xassert.IsNil(t, xr.GET("/user/:name", handle("default")))
+	xassert.IsNil(t, xr.Host("api.example.com").GET("/user/:name", handle("api")))
+	xassert.IsNil(t, xr.Host(":tenant.example.com").GET("/user/:name", handle("tenant")))
+	xassert.IsNil(t, xr.Host("*sub.example.org").Group("/v1").GET("/user/:name", handle("sub")))
+	xassert.IsNil(t, xr.Scope(Scope{Header: "X-Api-Version", HeaderValue: "2"}).GET("/user/:name", handle("header")))
+	xassert.IsNil(t, xr.Scope(Scope{Query: "debug"}).GET("/user/:name", handle("query")))
+
+	tests := []struct {
+		host   string
+		header string
+		target string
+		code   int
+		body   string
+	}{
+		{"api.example.com", "", "/user/blinklv", 200, "api:name=blinklv"},
+		{"API.Example.com:8080", "", "/user/blinklv", 200, "api:name=blinklv"},
+		{"foo.example.com", "", "/user/blinklv", 200, "tenant:tenant=foo,name=blinklv"},
+		{"a.foo.example.org", "", "/v1/user/blinklv", 200, "sub:sub=a.foo,name=blinklv"},
+		// The scope has no route for the path, so the default routes are used.
+		{"a.foo.example.org", "", "/user/blinklv", 200, "default:name=blinklv"},
+		{"example.net", "2", "/user/blinklv", 200, "header:name=blinklv"},
+		{"example.net", "1", "/user/blinklv", 200, "default:name=blinklv"},
+		{"example.net", "", "/user/blinklv?debug", 200, "query:name=blinklv"},
+		{"example.net", "", "/user/blinklv", 200, "default:name=blinklv"},
+		// The first matching scope wins.
+		{"api.example.com", "2", "/user/blinklv?debug", 200, "api:name=blinklv"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.target, nil)
+		r.Host = test.host
+		if test.header != "" {
+			r.Header.Set("X-Api-Version", test.header)
+		}
+		w := serveRequest(xr, r)
+		xassert.Equal(t, w.Code, test.code)
+		if test.code == 200 {
+			xassert.Equal(t, w.Body.String(), test.body)
+		}
+	}
+
+	// The 405 is computed against the routes of the selected scope.
+	r := httptest.NewRequest("POST", "/user/blinklv", nil)
+	r.Host = "api.example.com"
+	xassert.Equal(t, serveRequest(xr, r).Code, 405)
+	xassert.IsNil(t, xr.Host("api.example.com").POST("/user", handle("api")))
+	r = httptest.NewRequest("POST", "/user", nil)
+	r.Host = "api.example.com"
+	xassert.Equal(t, serveRequest(xr, r).Code, 200)
+	r = httptest.NewRequest("GET", "/user", nil)
+	r.Host = "api.example.com"
+	w := serveRequest(xr, r)
+	xassert.Equal(t, w.Code, 405)
+	xassert.Equal(t, w.Header().Get("Allow"), "POST, OPTIONS")
+
+	// The scoped routes are independent of the default routes.
+	xassert.NotNil(t, xr.Remove("POST", "/user"))
+	xassert.IsNil(t, xr.Host("api.example.com").Remove("POST", "/user"))
+	xassert.NotNil(t, xr.Host("api.example.com").Remove("POST", "/user"))
+	xassert.NotNil(t, xr.Host("www.example.com").Remove("GET", "/user/:name"))
+	xassert.IsNil(t, xr.Host("api.example.com").Remove("GET", "/user/:name"))
+	r = httptest.NewRequest("GET", "/user/blinklv", nil)
+	r.Host = "api.example.com"
+	// The next scope matching the request and having the path is selected.
+	xassert.Equal(t, serveRequest(xr, r).Body.String(), "tenant:tenant=api,name=blinklv")
+
+	// The route can be added again after it has been removed.
+	xassert.IsNil(t, xr.Host("api.example.com").GET("/user/:name", handle("api")))
+	xassert.Equal(t, serveRequest(xr, r).Body.String(), "api:name=blinklv")
+
+	// Invalid scopes.
+	xassert.NotNil(t, xr.Host("a..example.com").GET("/", handle("invalid")))
+	xassert.NotNil(t, xr.Scope(Scope{HeaderValue: "2"}).GET("/", handle("invalid")))
+	xassert.NotNil(t, xr.Scope(Scope{QueryValue: "1"}).GET("/", handle("invalid")))
+}
+
+func TestScopeAddAndRemove(t *testing.T) {
+	xr := New(&XConfig{})
+	handle := func(name string) XHandle {
+		return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+			w.Write([]byte(name))
+		}
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Host = "api.example.com"
+		return serveRequest(xr, r)
+	}
+
+	xassert.IsNil(t, xr.GET("/health", handle("default")))
+	xassert.Equal(t, get("/health").Body.String(), "default")
+
+	// The paths which the scope doesn't have are still served by the default routes.
+	api := xr.Host("api.example.com")
+	xassert.IsNil(t, api.GET("/user", handle("api")))
+	xassert.Equal(t, get("/user").Body.String(), "api")
+	xassert.Equal(t, get("/health").Body.String(), "default")
+
+	// The scope has the path, so the default routes aren't considered.
+	xassert.IsNil(t, api.POST("/health", handle("api")))
+	xassert.Equal(t, get("/health").Code, 404)
+	xassert.IsNil(t, api.Remove("POST", "/health"))
+	xassert.Equal(t, get("/health").Body.String(), "default")
+
+	// The scope has no route after removing, it doesn't affect any request.
+	xassert.IsNil(t, api.Remove("GET", "/user"))
+	xassert.Equal(t, get("/user").Code, 404)
+	xassert.Equal(t, get("/health").Body.String(), "default")
+	xassert.Equal(t, xr.Routes(), []Route{{Method: "GET", Path: "/health"}})
+}
+
+func TestScopeRoutes(t *testing.T) {
+	xr := New(&XConfig{})
+	handle := func(w http.ResponseWriter, r *http.Request, xps XParams) {}
+
+	api := xr.Host("api.example.com")
+	xassert.IsNil(t, xr.GET("/user/:name", handle))
+	xassert.IsNil(t, api.GET("/user/:name", handle))
+	xassert.IsNil(t, api.HandleNamed("user", "POST", "/user/:name", handle))
+
+	xassert.Equal(t, xr.Routes(), []Route{
+		{Method: "GET", Path: "/user/:name"},
+		{Method: "GET", Path: "/user/:name", Scope: Scope{Host: "api.example.com"}},
+		{Method: "POST", Path: "/user/:name", Name: "user", Scope: Scope{Host: "api.example.com"}},
+	})
+	xassert.Equal(t, xr.Routes()[1].String(), "GET /user/:name host=api.example.com")
+
+	u, err := xr.URL("user", XParams{{"name", "blinklv"}})
+	xassert.IsNil(t, err)
+	xassert.Equal(t, u, "/user/blinklv")
+
+	// Removing the default route doesn't release the name of the scoped one.
+	xassert.IsNil(t, xr.HandleNamed("default", "POST", "/user/:name", handle))
+	xassert.IsNil(t, xr.Remove("POST", "/user/:name"))
+	_, err = xr.URL("user", XParams{{"name", "blinklv"}})
+	xassert.IsNil(t, err)
+	_, err = xr.URL("default", XParams{{"name", "blinklv"}})
+	xassert.NotNil(t, err)
+
+	xassert.IsNil(t, api.Remove("POST", "/user/:name"))
+	_, err = xr.URL("user", XParams{{"name", "blinklv"}})
+	xassert.NotNil(t, err)
+
+	// Only the default routes are searched by 'Lookup' method.
+	xassert.IsNil(t, xr.Remove("GET", "/user/:name"))
+	h, _ := xr.Lookup("GET", "/user/blinklv")
+	xassert.IsNil(t, h)
+}
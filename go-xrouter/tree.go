@@ -3,59 +3,95 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2017-05-26
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2017-07-07
+// Last Change: 2026-10-17
 
 package xrouter
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
-// 'tree' contains the root node of the tree, and it also
-// has a read-write lock to make it safe in concurrent scenario.
+// 'tree' contains the root node of the tree, it's copy-on-write to make it
+// safe in concurrent scenario. 'add' and 'remove' copy the nodes on the path
+// from the root to the modified node, share the other subtrees with the old
+// root, then replace the root atomically, so 'get' never locks and always
+// sees a complete tree. 'mtx' is only used to serialize modifications.
 type tree struct {
-	rwmtx *sync.RWMutex
-	n     *node
+	mtx  sync.Mutex
+	root atomic.Value // The type of the value is *node.
 }
 
+func newTree() *tree {
+	t := &tree{}
+	t.root.Store(&node{})
+	return t
+}
+
+// Get the current root node, it mustn't be modified.
+func (t *tree) load() *node {
+	return t.root.Load().(*node)
+}
+
+// Only the nodes on the path of the modification are copied, so the cost of
+// a modification is proportional to the depth of the tree instead of the
+// number of nodes.
 func (t *tree) add(path string, handle XHandle) error {
-	t.rwmtx.Lock()
-	err := t.n.add(path, path, handle)
-	t.rwmtx.Unlock()
-	return err
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	n := t.load().copy()
+	if err := n.add(path, path, handle); err != nil {
+		return err
+	}
+	t.root.Store(n)
+	return nil
 }
 
 func (t *tree) remove(path string) error {
-	t.rwmtx.Lock()
-	ok := t.n.remove(path)
-	t.rwmtx.Unlock()
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
 
-	if ok {
-		return nil
-	} else {
+	n := t.load().copy()
+	if !n.remove(path) {
 		return fmt.Errorf("path (%s) hasn't been registered", path)
 	}
+	t.root.Store(n)
+	return nil
 }
 
 func (t *tree) get(path string, enableTSR bool) (h XHandle, xps XParams, tsr tsrType) {
-	t.rwmtx.RLock()
-	if len(t.n.path) > 0 {
-		h, xps, tsr = t.n.get(path, enableTSR)
+	if n := t.load(); len(n.path) > 0 {
+		h, xps, tsr = n.get(path, enableTSR)
 	}
-	t.rwmtx.RUnlock()
 	return
 }
 
+// Call the 'fn' function for each registered path in the tree, the order is
+// same as the order of the nodes.
+func (t *tree) walk(fn func(path string, handle XHandle)) {
+	if n := t.load(); len(n.path) > 0 {
+		n.walk("", fn)
+	}
+}
+
+// Print the tree, see 'print' method of node.
+func (t *tree) fprint(w io.Writer) {
+	if n := t.load(); len(n.path) > 0 {
+		n.fprint(w, 0)
+	}
+}
+
 // If the path length of the root node is zero, which
 // represent this tree is empty.
 func (t *tree) isempty() bool {
-	t.rwmtx.RLock()
-	result := len(t.n.path) == 0
-	t.rwmtx.RUnlock()
-	return result
+	return len(t.load().path) == 0
 }
 
 type nodeType uint8
@@ -89,6 +125,9 @@ type node struct {
 	priority  uint32
 	children  nodes
 	handle    XHandle
+
+	// The constraint of a param node, it's nil if the param has no constraint.
+	re *regexp.Regexp
 }
 
 // Register a new handle with the given path. If the path conflicts with
@@ -182,13 +221,13 @@ func (n *node) remove(path string) (ok bool) {
 	} else if i == len(n.path) && i < len(path) {
 		var k int
 		for k = 0; k < len(n.children); k++ {
-			if n.children[k].path[0] == path[i] {
+			if c := n.children[k]; c.path[0] == path[i] && (c.nt != param || paramHead(c.path) == paramHead(path[i:])) {
 				break
 			}
 		}
 
 		if k < len(n.children) {
-			if ok = n.children[k].remove(path[i:]); ok {
+			if ok = n.own(n.children[k]).remove(path[i:]); ok {
 				if n.children[k].priority == 0 {
 					n.children = append(n.children[:k], n.children[k+1:]...)
 				}
@@ -216,10 +255,13 @@ func (n *node) concat() {
 	child := n.children[0]
 	if child.nt == static {
 		if n.nt == static || (n.nt == param && child.path == "/") {
+			// The children of the child may be shared with an old tree, so
+			// they're copied instead of being taken over.
 			n.path += child.path
-			n.children, n.handle = child.children, child.handle
+			n.children, n.handle = child.children.copy(), child.handle
 		} else if n.nt == param && child.index == byte('/') {
 			// The prefix of child's path is '/', but the child's path is not equal to "/".
+			child = n.own(child)
 			n.path += "/"
 			child.path, child.index = child.path[1:], child.path[1]
 		}
@@ -235,14 +277,36 @@ func (n *node) next(i int, path, full string, handle XHandle) (err error) {
 			// a conflict.
 			if n.children[0].nt == static {
 				// The result must be non-nil.
-				err = n.children[0].add(path[i:], full, handle)
+				err = n.own(n.children[0]).add(path[i:], full, handle)
 				return
 			}
 		}
 
 		var child *node
 
-		if child = n.child(path[i]); child != nil {
+		if path[i] == ':' && len(n.children) > 0 && n.children[0].nt == param {
+			head := paramHead(path[i:])
+			if child = n.paramChild(head); child == nil {
+				// The param siblings with different constraints are allowed,
+				// but only one of them can be unconstrained. Adding another
+				// unconstrained one to it will report the conflict.
+				if child = n.paramChild(""); child == nil || strings.IndexByte(head, '<') != -1 {
+					child = &node{}
+					if err = child.construct(path[i:], full, handle); err == nil {
+						n.children = append(n.children, child)
+						n.resort()
+					}
+					goto done
+				}
+			}
+		}
+
+		if child == nil {
+			child = n.child(path[i])
+		}
+
+		if child != nil {
+			child = n.own(child)
 			if err = child.add(path[i:], full, handle); err == nil {
 				n.resort()
 			}
@@ -256,6 +320,7 @@ func (n *node) next(i int, path, full string, handle XHandle) (err error) {
 			}
 		}
 
+	done:
 		if err == nil {
 			if n.nt == static {
 				n.maxParams = max(n.maxParams, child.maxParams)
@@ -275,15 +340,14 @@ func (n *node) construct(path string, full string, handle XHandle) (err error) {
 	switch path[0] {
 	case ':':
 		n.nt = param
-		if i = strings.IndexAny(path[1:], ":*/"); i > 0 {
-			// NOTE: 'i' is based on 'path[1:]', not 'path', so
-			// we have got to add 1 to it.
-			i++
-			if path[i] != '/' {
-				err = fmt.Errorf("'%s' in path '%s': only one wildcard per path segment is allowed", path, full)
-				break
-			}
+		// The constraint may contain ':' and '*', so the end of the path
+		// segment is determined by '/' only.
+		seg := paramHead(path)
+		if n.re, err = parseParam(seg, full); err != nil {
+			break
+		}
 
+		if i = len(seg); i < len(path) {
 			i++
 			n.path, n.maxParams = path[:i], 1
 			if i < len(path) {
@@ -295,10 +359,8 @@ func (n *node) construct(path string, full string, handle XHandle) (err error) {
 			} else {
 				n.handle = handle
 			}
-		} else if i == -1 && len(path) > 1 {
-			n.path, n.maxParams, n.handle = path, 1, handle
 		} else {
-			err = fmt.Errorf("'%s' in path '%s': param wildcard can't be empty", path, full)
+			n.path, n.maxParams, n.handle = path, 1, handle
 		}
 	case '*':
 		n.nt = all
@@ -333,7 +395,7 @@ func (n *node) split(i int, handle XHandle) error {
 		n.path, n.children = n.path[:i], []*node{&child}
 
 		if n.nt == param {
-			child.nt, child.maxParams = static, child.maxParams-1
+			child.nt, child.maxParams, child.re = static, child.maxParams-1, nil
 		}
 
 		if handle != nil {
@@ -404,10 +466,10 @@ outer:
 				if xps == nil {
 					xps = make(XParams, 0, n.maxParams)
 				}
-				xps = append(xps, XParam{Key: n.path[1 : len(n.path)-1], Value: path[:i]})
+				xps = append(xps, XParam{Key: paramKey(n.path), Value: path[:i]})
 				i++
 			} else if i > 0 {
-				xps = append(xps, XParam{Key: n.path[1:], Value: path[:i]})
+				xps = append(xps, XParam{Key: paramKey(n.path), Value: path[:i]})
 			}
 		case all:
 			xps = append(xps, XParam{Key: n.path[1:], Value: path})
@@ -417,6 +479,16 @@ outer:
 		if n.nt != static || i == len(n.path) {
 			if i < len(path) {
 				if child := n.child(path[i]); child != nil {
+					if child.nt == param && (child.re != nil || len(n.children) > 1) {
+						if ch, cxps, ctsr, ok := n.getParam(path[i:], enableTSR); ok {
+							if ch != nil {
+								xps = append(xps, cxps...)
+							}
+							return ch, xps, ctsr
+						}
+						// None of the constraints is satisfied.
+						break outer
+					}
 					parent, n, path = n, child, path[i:]
 					continue
 				}
@@ -435,6 +507,61 @@ outer:
 	return
 }
 
+// Try the param children whose constraints are satisfied by the value, the
+// constrained ones take precedence over the unconstrained one. The first
+// handle found is returned, otherwise the first TSR recommendation is
+// returned. The 'ok' result is false if none of the constraints is satisfied.
+func (n *node) getParam(path string, enableTSR bool) (h XHandle, xps XParams, tsr tsrType, ok bool) {
+	var (
+		value = paramHead(path)
+		last  *node // The unconstrained one.
+	)
+
+	for _, c := range n.children {
+		if c.re == nil {
+			last = c
+			continue
+		}
+
+		if c.re.MatchString(value) {
+			ok = true
+			ch, cxps, ctsr := c.get(path, enableTSR)
+			if ch != nil {
+				return ch, cxps, notRedirect, true
+			}
+			if tsr == notRedirect {
+				tsr = ctsr
+			}
+		}
+	}
+
+	if last != nil {
+		ok = true
+		ch, cxps, ctsr := last.get(path, enableTSR)
+		if ch != nil {
+			return ch, cxps, notRedirect, true
+		}
+		if tsr == notRedirect {
+			tsr = ctsr
+		}
+	}
+	return
+}
+
+// Find the param child with the given head, the empty head represents the
+// unconstrained one.
+func (n *node) paramChild(head string) *node {
+	for _, c := range n.children {
+		if c.nt != param {
+			continue
+		}
+		if head == "" && c.re == nil || paramHead(c.path) == head {
+			return c
+		}
+	}
+	return nil
+}
+
 func (n *node) canTSR(parent *node, path string, i int) tsrType {
 	if len(path) == 0 || path[len(path)-1] != '/' {
 		switch n.nt {
@@ -491,6 +618,29 @@ func (n *node) child(index byte) *node {
 	return nil
 }
 
+// Copy the node without its descendants. The children slice is copied, so
+// the copy can add, remove and resort its children without affecting the
+// original node, but the children themselves are shared.
+func (n *node) copy() *node {
+	c := *n
+	c.children = n.children.copy()
+	return &c
+}
+
+// Replace a child with its copy before modifying it, the original child may
+// be shared with an old tree. The copy is returned. The node itself must have
+// been copied.
+func (n *node) own(child *node) *node {
+	for i, c := range n.children {
+		if c == child {
+			child = c.copy()
+			n.children[i] = child
+			break
+		}
+	}
+	return child
+}
+
 // Resort the children by the priority.
 func (n *node) resort() {
 	if n != nil && !sort.IsSorted(n.children) {
@@ -500,6 +650,13 @@ func (n *node) resort() {
 
 type nodes []*node
 
+func (ns nodes) copy() nodes {
+	if ns == nil {
+		return nil
+	}
+	return append(make(nodes, 0, len(ns)), ns...)
+}
+
 // Impelment sort.Interface.
 func (ns nodes) Len() int {
 	return len(ns)
@@ -528,6 +685,19 @@ func max(a, b uint8) uint8 {
 	return b
 }
 
+// Walk a node recursively, the full path of a node is the concatenation of
+// the paths of its ancestors and itself.
+func (n *node) walk(prefix string, fn func(path string, handle XHandle)) {
+	prefix += n.path
+	if n.handle != nil {
+		fn(prefix, n.handle)
+	}
+
+	for _, child := range n.children {
+		child.walk(prefix, fn)
+	}
+}
+
 // Find the longest common prefix.
 func lcp(a, b string) int {
 	var i, max = 0, min(len(a), len(b))
@@ -541,12 +711,23 @@ func lcp(a, b string) int {
 
 // Print a node recursively.
 func (n *node) print(indent int) {
+	n.fprint(os.Stdout, indent)
+}
+
+// Print a node recursively to the writer.
+func (n *node) fprint(w io.Writer, indent int) {
 	// Format:
 	// priority:[index] path (nt:maxParams:handle)
-	fmt.Printf("%s%d:[%c] %s (%s:%d:%v)\n", strings.Repeat("  ", indent), n.priority, n.index, n.path, n.nt, n.maxParams, n.handle)
+	//
+	// The index of a wildcard node is zero, it's printed as a space.
+	index := n.index
+	if index == 0 {
+		index = ' '
+	}
+	fmt.Fprintf(w, "%s%d:[%c] %s (%s:%d:%v)\n", strings.Repeat("  ", indent), n.priority, index, n.path, n.nt, n.maxParams, n.handle != nil)
 
 	for _, child := range n.children {
-		child.print(indent + 1)
+		child.fprint(w, indent+1)
 	}
 }
 
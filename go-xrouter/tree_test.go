@@ -3,15 +3,17 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2017-06-13
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2017-07-18
+// Last Change: 2026-10-17
 package xrouter
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/X-Plan/xgo/go-xassert"
 	"github.com/X-Plan/xgo/go-xrandstring"
 	"math/rand"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -198,6 +200,122 @@ func TestAddAndRemove(t *testing.T) {
 	}
 }
 
+func TestTreeCopyOnWrite(t *testing.T) {
+	var (
+		tr    = newTree()
+		paths []string
+	)
+	for _, p := range validPaths() {
+		xassert.IsNil(t, tr.add(p, generateHandle("GET", p)))
+		paths = append(paths, p)
+	}
+
+	dump := func(n *node) string {
+		buf := &bytes.Buffer{}
+		n.fprint(buf, 0)
+		return buf.String()
+	}
+
+	// The old root nodes aren't modified by the following modifications.
+	var (
+		roots []*node
+		dumps []string
+	)
+	modify := func(err error) {
+		xassert.IsNil(t, err)
+		for i, root := range roots {
+			xassert.Equal(t, dump(root), dumps[i])
+			xassert.IsNil(t, root.check())
+		}
+		roots, dumps = append(roots, tr.load()), append(dumps, dump(tr.load()))
+		xassert.IsNil(t, tr.load().check())
+	}
+
+	modify(nil)
+	modify(tr.add("/cow/:name", generateHandle("GET", "/cow/:name")))
+	for _, p := range paths {
+		modify(tr.remove(p))
+		if strings.HasPrefix(p, "/update/") {
+			modify(tr.add(p, generateHandle("GET", p)))
+		}
+	}
+
+	// The subtrees which aren't on the path of a modification are shared.
+	root := tr.load()
+	xassert.IsNil(t, tr.add("/dog/:name", generateHandle("GET", "/dog/:name")))
+	var shared int
+	for _, c := range tr.load().children {
+		for _, oc := range root.children {
+			if c == oc {
+				shared++
+			}
+		}
+	}
+	xassert.Equal(t, shared, len(root.children))
+
+	// The failed modifications don't replace the root node.
+	root = tr.load()
+	xassert.NotNil(t, tr.add("/cow/:nick", generateHandle("GET", "/cow/:nick")))
+	xassert.NotNil(t, tr.remove(paths[0]))
+	xassert.IsTrue(t, tr.load() == root)
+}
+
+func TestTreeConcurrent(t *testing.T) {
+	var (
+		tr   = newTree()
+		wg   = &sync.WaitGroup{}
+		stop = make(chan struct{})
+	)
+
+	static := []string{"/", "/user/:name", "/user/:name/repos", "/files/*path"}
+	for _, p := range static {
+		xassert.IsNil(t, tr.add(p, generateHandle("GET", p)))
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// The paths which aren't modified are always found.
+				path, _ := generatePath(static[j%len(static)])
+				if h, _, _ := tr.get(path, false); h == nil {
+					t.Errorf("path (%s) isn't found", path)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 200; i++ {
+		p := fmt.Sprintf("/user/:name/dynamic%d", i%10)
+		if i < 10 {
+			xassert.IsNil(t, tr.add(p, generateHandle("GET", p)))
+		} else {
+			xassert.IsNil(t, tr.remove(p))
+			xassert.IsNil(t, tr.add(p, generateHandle("GET", p)))
+		}
+	}
+	close(stop)
+	wg.Wait()
+	xassert.IsNil(t, tr.load().check())
+}
+
+// Get the valid paths of the 'paths' variable.
+func validPaths() (result []string) {
+	for _, p := range paths {
+		if p.ok {
+			result = append(result, p.path)
+		}
+	}
+	return
+}
+
 // Replace wildcard with a random string. We think all pattern is valid.
 func generatePath(pattern string) (path string, xps XParams) {
 	for len(pattern) > 0 {
@@ -243,3 +361,128 @@ func randomSortPaths(paths []string) {
 		paths[i], paths[j] = paths[j], paths[i]
 	}
 }
+
+// lockedTree is the previous implementation of tree, the root node is
+// protected by a read-write lock. It's only used to compare the performance.
+type lockedTree struct {
+	rwmtx sync.RWMutex
+	n     *node
+}
+
+func (t *lockedTree) add(path string, handle XHandle) error {
+	t.rwmtx.Lock()
+	err := t.n.add(path, path, handle)
+	t.rwmtx.Unlock()
+	return err
+}
+
+func (t *lockedTree) get(path string, enableTSR bool) (h XHandle, xps XParams, tsr tsrType) {
+	t.rwmtx.RLock()
+	if len(t.n.path) > 0 {
+		h, xps, tsr = t.n.get(path, enableTSR)
+	}
+	t.rwmtx.RUnlock()
+	return
+}
+
+func BenchmarkTree(b *testing.B) {
+	var (
+		lt     = &lockedTree{n: &node{}}
+		ct     = newTree()
+		routes = []string{
+			"/", "/about", "/about/contact", "/help", "/help/faq",
+			"/user/:name", "/user/:name/repos", "/user/:name/repos/:repo",
+			"/user/:name/repos/:repo/issues/:number", "/orgs/:org/members",
+			"/static/*filepath", "/download/:version/*filepath",
+		}
+		tests = []struct {
+			name string
+			path string
+		}{
+			{"Static", "/about/contact"},
+			{"Param", "/user/blinklv/repos/xgo/issues/10"},
+			{"CatchAll", "/static/js/app/main.js"},
+		}
+	)
+
+	for _, route := range routes {
+		h := generateHandle("GET", route)
+		if err := lt.add(route, h); err != nil {
+			b.Fatal(err)
+		}
+		if err := ct.add(route, h); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, test := range tests {
+		path := test.path
+		if h, _, _ := ct.get(path, false); h == nil {
+			b.Fatalf("path (%s) isn't found", path)
+		}
+
+		b.Run(test.name+"/Locked", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				lt.get(path, false)
+			}
+		})
+
+		b.Run(test.name+"/CopyOnWrite", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ct.get(path, false)
+			}
+		})
+
+		b.Run(test.name+"/LockedParallel", func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					lt.get(path, false)
+				}
+			})
+		})
+
+		b.Run(test.name+"/CopyOnWriteParallel", func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					ct.get(path, false)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkTreeAdd(b *testing.B) {
+	var routes []string
+	for i := 0; i < 1000; i++ {
+		switch i % 3 {
+		case 0:
+			routes = append(routes, fmt.Sprintf("/static%d/page%d", i%10, i))
+		case 1:
+			routes = append(routes, fmt.Sprintf("/param%d/user%d/:name", i%10, i))
+		case 2:
+			routes = append(routes, fmt.Sprintf("/all%d/files%d/*filepath", i%10, i))
+		}
+	}
+
+	b.Run("Locked", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			lt := &lockedTree{n: &node{}}
+			for _, route := range routes {
+				if err := lt.add(route, generateHandle("GET", route)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("CopyOnWrite", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ct := newTree()
+			for _, route := range routes {
+				if err := ct.add(route, generateHandle("GET", route)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,211 @@
+// tx.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+
+package xrouter
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Throw this error when operating on a finished transaction.
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// Throw this error when committing a transaction if the routes of the XRouter
+// have been modified after the transaction began.
+var ErrTxConflict = errors.New("routes have been modified after the transaction began")
+
+// XTx is a transaction of an XRouter. The routes are modified off to the side,
+// and they replace the routes of the XRouter atomically when the transaction
+// is committed, so the in-flight requests see either the old routes or the
+// new routes, never a mix of them. The global middlewares and the settings of
+// the XRouter aren't affected.
+type XTx struct {
+	xr      *XRouter
+	mtx     sync.RWMutex
+	rs      *routeSet // It's nil if the transaction has finished.
+	version uint64
+}
+
+// Begin starts a transaction, it contains a copy of the current routes of
+// the XRouter (call 'Clear' method to start from scratch). The transaction
+// fails to commit if the routes of the XRouter are modified after it begins,
+// including the modifications made by other transactions.
+//
+//	tx := xr.Begin()
+//	tx.Clear()
+//	tx.Handle("GET", "/user/:name", GetUser)
+//	tx.Host("api.example.com").GET("/user/:name", GetAPIUser)
+//	if err := tx.Commit(); err != nil {
+//		// Retry or give up.
+//	}
+func (xr *XRouter) Begin() *XTx {
+	xr.rmtx.RLock()
+	defer xr.rmtx.RUnlock()
+
+	// The version must be loaded before copying the routes, so the concurrent
+	// modifications which aren't copied will be detected.
+	version := atomic.LoadUint64(&xr.version)
+	return &XTx{xr: xr, rs: xr.current().clone(), version: version}
+}
+
+// Handle registers a new request handle with the given method and path in the
+// transaction, see 'Handle' method of XRouter.
+func (tx *XTx) Handle(method, path string, handle XHandle) error {
+	return tx.handle(Scope{}, method, path, handle)
+}
+
+// HandleNamed registers a new named route in the transaction, see
+// 'HandleNamed' method of XRouter.
+func (tx *XTx) HandleNamed(name, method, path string, handle XHandle) error {
+	return tx.handleNamed(Scope{}, name, method, path, handle)
+}
+
+// Any registers a new request handle with the given path for all standard
+// methods in the transaction, see 'Any' method of XRouter.
+func (tx *XTx) Any(path string, handle XHandle) error {
+	return tx.any(Scope{}, path, handle)
+}
+
+// Remove unregisters an existing request handle with the given method and
+// path in the transaction, see 'Remove' method of XRouter.
+func (tx *XTx) Remove(method, path string) error {
+	return tx.remove(Scope{}, method, path)
+}
+
+// Group returns an XGroup whose routes are registered in the transaction, see
+// 'Group' method of XRouter.
+func (tx *XTx) Group(prefix string, middlewares ...Middleware) *XGroup {
+	return &XGroup{
+		reg:         tx,
+		prefix:      strings.TrimSuffix(prefix, "/"),
+		middlewares: middlewares,
+	}
+}
+
+// Scope returns an XGroup whose routes are registered in the transaction and
+// only match the requests in the scope, see 'Scope' method of XRouter.
+func (tx *XTx) Scope(s Scope) *XGroup {
+	return &XGroup{reg: tx, scope: s}
+}
+
+// Host is a shortcut for Scope(Scope{Host: pattern}).
+func (tx *XTx) Host(pattern string) *XGroup {
+	return tx.Scope(Scope{Host: pattern})
+}
+
+// Clear removes all routes (including the scoped ones and the names) in the
+// transaction.
+func (tx *XTx) Clear() error {
+	tx.mtx.Lock()
+	defer tx.mtx.Unlock()
+
+	if tx.rs == nil {
+		return ErrTxDone
+	}
+	tx.rs = newRouteSet()
+	return nil
+}
+
+// Routes returns all routes in the transaction, see 'Routes' method of XRouter.
+func (tx *XTx) Routes() []Route {
+	tx.mtx.RLock()
+	defer tx.mtx.RUnlock()
+
+	if tx.rs == nil {
+		return nil
+	}
+	return tx.rs.routes()
+}
+
+// Commit replaces the routes of the XRouter with the routes in the transaction.
+// If the routes of the XRouter have been modified after the transaction began,
+// nothing is replaced and ErrTxConflict is returned. The transaction finishes
+// whether it succeeds or not.
+func (tx *XTx) Commit() error {
+	tx.mtx.Lock()
+	defer tx.mtx.Unlock()
+
+	if tx.rs == nil {
+		return ErrTxDone
+	}
+
+	rs, xr := tx.rs, tx.xr
+	tx.rs = nil
+
+	xr.rmtx.Lock()
+	defer xr.rmtx.Unlock()
+
+	if atomic.LoadUint64(&xr.version) != tx.version {
+		return ErrTxConflict
+	}
+	xr.rs.Store(rs)
+	atomic.AddUint64(&xr.version, 1)
+	return nil
+}
+
+// Rollback discards the routes in the transaction, the transaction finishes.
+func (tx *XTx) Rollback() error {
+	tx.mtx.Lock()
+	defer tx.mtx.Unlock()
+
+	if tx.rs == nil {
+		return ErrTxDone
+	}
+	tx.rs = nil
+	return nil
+}
+
+func (tx *XTx) handle(s Scope, method, path string, handle XHandle) error {
+	return tx.modify(func(rs *routeSet) error { return rs.handle(s, method, path, handle) })
+}
+
+func (tx *XTx) handleNamed(s Scope, name, method, path string, handle XHandle) error {
+	return tx.modify(func(rs *routeSet) error { return rs.handleNamed(s, name, method, path, handle) })
+}
+
+func (tx *XTx) any(s Scope, path string, handle XHandle) error {
+	return tx.modify(func(rs *routeSet) error { return rs.any(s, path, handle) })
+}
+
+func (tx *XTx) remove(s Scope, method, path string) error {
+	return tx.modify(func(rs *routeSet) error { return rs.remove(s, method, path) })
+}
+
+// Apply a modification to the routes in the transaction.
+func (tx *XTx) modify(fn func(*routeSet) error) error {
+	tx.mtx.RLock()
+	defer tx.mtx.RUnlock()
+
+	if tx.rs == nil {
+		return ErrTxDone
+	}
+	return fn(tx.rs)
+}
+
+// Copy the routes, so the copy can be modified independently. The trees are
+// copy-on-write, so their current root nodes are shared. The handles and the
+// named routes are immutable, so they're shared too.
+func (rs *routeSet) clone() *routeSet {
+	c := newRouteSet()
+	rs.eachTable(func(s Scope, tb *table) {
+		// The Scope has been validated, so it won't fail.
+		ctb, _ := c.table(s, true)
+		for method, t := range tb.load() {
+			ctb.getTree(method, true).root.Store(t.load())
+		}
+	})
+
+	rs.nmtx.RLock()
+	for name, nr := range rs.names {
+		c.names[name] = nr
+	}
+	rs.nmtx.RUnlock()
+	return c
+}
@@ -0,0 +1,161 @@
+// tx_test.go
+//
+// Author: blinklv <blinklv@icloud.com>
+// Create Time: 2026-10-17
+// Maintainer: blinklv <blinklv@icloud.com>
+// Last Change: 2026-10-17
+package xrouter
+
+import (
+	"fmt"
+	"github.com/X-Plan/xgo/go-xassert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestTx(t *testing.T) {
+	xr := New(&XConfig{})
+	handle := func(name string) XHandle {
+		return func(w http.ResponseWriter, r *http.Request, xps XParams) {
+			w.Write([]byte(name + ":" + xps.String()))
+		}
+	}
+
+	xassert.IsNil(t, xr.HandleNamed("user", "GET", "/user/:id<int>", handle("old")))
+	xassert.IsNil(t, xr.GET("/files/*path", handle("old")))
+	xassert.IsNil(t, xr.Host(":tenant.example.com").GET("/", handle("old")))
+
+	// The transaction contains a copy of the current routes.
+	tx := xr.Begin()
+	xassert.Equal(t, tx.Routes(), xr.Routes())
+
+	xassert.IsNil(t, tx.Remove("GET", "/files/*path"))
+	xassert.IsNil(t, tx.Group("/api", generateMiddleware("a")).GET("/user/:name", handle("new")))
+	xassert.IsNil(t, tx.Host(":tenant.example.com").POST("/", handle("new")))
+	xassert.NotNil(t, tx.Handle("GET", "/user/:id<int>", handle("new")))
+
+	// The XRouter isn't affected before committing.
+	xassert.Equal(t, serve(xr, "GET", "/files/a/b").Body.String(), "old:path=a/b")
+	xassert.Equal(t, serve(xr, "GET", "/api/user/blinklv").Code, 404)
+
+	xassert.IsNil(t, tx.Commit())
+	xassert.Equal(t, serve(xr, "GET", "/files/a/b").Code, 404)
+	xassert.Equal(t, serve(xr, "GET", "/api/user/blinklv").Body.String(), "a>new:name=blinklv")
+	xassert.Equal(t, serve(xr, "GET", "/user/10").Body.String(), "old:id=10")
+	u, err := xr.URL("user", XParams{{"id", "10"}})
+	xassert.IsNil(t, err)
+	xassert.Equal(t, u, "/user/10")
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Host = "foo.example.com"
+	xassert.Equal(t, serveRequest(xr, r).Body.String(), "new:tenant=foo")
+
+	// The transaction has finished.
+	xassert.Equal(t, tx.Handle("GET", "/foo", handle("new")), ErrTxDone)
+	xassert.Equal(t, tx.Commit(), ErrTxDone)
+	xassert.Equal(t, tx.Rollback(), ErrTxDone)
+	xassert.Equal(t, tx.Clear(), ErrTxDone)
+	xassert.IsNil(t, tx.Routes())
+
+	// The routes of the XRouter can be modified after committing.
+	xassert.IsNil(t, xr.Remove("GET", "/user/:id<int>"))
+	_, err = xr.URL("user", XParams{{"id", "10"}})
+	xassert.NotNil(t, err)
+
+	// Start from scratch.
+	tx = xr.Begin()
+	xassert.IsNil(t, tx.Clear())
+	xassert.Equal(t, len(tx.Routes()), 0)
+	xassert.IsNil(t, tx.Handle("GET", "/", handle("scratch")))
+	xassert.IsNil(t, tx.Commit())
+	xassert.Equal(t, xr.Routes(), []Route{{Method: "GET", Path: "/"}})
+
+	// Roll back.
+	tx = xr.Begin()
+	xassert.IsNil(t, tx.Remove("GET", "/"))
+	xassert.IsNil(t, tx.Rollback())
+	xassert.Equal(t, serve(xr, "GET", "/").Body.String(), "scratch:")
+}
+
+func TestTxConflict(t *testing.T) {
+	xr := New(&XConfig{})
+	handle := func(w http.ResponseWriter, r *http.Request, xps XParams) {}
+
+	// The routes of the XRouter are modified after the transaction began.
+	tx := xr.Begin()
+	xassert.IsNil(t, tx.Handle("GET", "/tx", handle))
+	xassert.IsNil(t, xr.GET("/xr", handle))
+	xassert.Equal(t, tx.Commit(), ErrTxConflict)
+	xassert.Equal(t, xr.Routes(), []Route{{Method: "GET", Path: "/xr"}})
+
+	// The failed modifications don't cause conflicts.
+	tx = xr.Begin()
+	xassert.NotNil(t, xr.GET("/xr", handle))
+	xassert.NotNil(t, xr.Remove("GET", "/none"))
+	xassert.IsNil(t, tx.Commit())
+
+	// Another transaction has been committed.
+	tx1, tx2 := xr.Begin(), xr.Begin()
+	xassert.IsNil(t, tx1.Commit())
+	xassert.Equal(t, tx2.Commit(), ErrTxConflict)
+}
+
+func TestTxConcurrent(t *testing.T) {
+	var (
+		xr   = New(&XConfig{})
+		wg   = &sync.WaitGroup{}
+		stop = make(chan struct{})
+	)
+
+	build := func(gen int) func(*XTx) error {
+		return func(tx *XTx) error {
+			handle := func(w http.ResponseWriter, r *http.Request, xps XParams) {
+				w.Write([]byte(fmt.Sprintf("%d", gen)))
+			}
+			tx.Clear()
+			for i := 0; i < 20; i++ {
+				if err := tx.Handle("GET", fmt.Sprintf("/route/%d", i), handle); err != nil {
+					return err
+				}
+			}
+			return tx.Handle("GET", fmt.Sprintf("/gen/%d", gen), handle)
+		}
+	}
+
+	tx := xr.Begin()
+	xassert.IsNil(t, build(0)(tx))
+	xassert.IsNil(t, tx.Commit())
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// The routes existing in all generations are always found.
+				if code := serve(xr, "GET", fmt.Sprintf("/route/%d", j%20)).Code; code != 200 {
+					t.Errorf("unexpected status code %d", code)
+					return
+				}
+			}
+		}()
+	}
+
+	for gen := 1; gen <= 100; gen++ {
+		tx := xr.Begin()
+		xassert.IsNil(t, build(gen)(tx))
+		xassert.IsNil(t, tx.Commit())
+	}
+	close(stop)
+	wg.Wait()
+
+	xassert.Equal(t, serve(xr, "GET", "/gen/100").Body.String(), "100")
+	xassert.Equal(t, serve(xr, "GET", "/gen/99").Code, 404)
+	xassert.Equal(t, len(xr.Routes()), 21)
+}
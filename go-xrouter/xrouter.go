@@ -3,7 +3,7 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2017-02-27
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2017-11-20
+// Last Change: 2026-10-17
 
 // Package go-xrouter is a trie based HTTP request router.
 //
@@ -15,8 +15,11 @@ package xrouter
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // XHandle is a function that can be registered to a route to handle HTTP
@@ -71,6 +74,46 @@ func (xps XParams) Get(name string) string {
 	return ""
 }
 
+// Int returns the value of the first XParam which key matches the given name
+// as an int. It's useful for the params constrained by '<int>' or '<uint>',
+// whose values have been validated when routing.
+func (xps XParams) Int(name string) (int, error) {
+	value, ok := xps.lookup(name)
+	if !ok {
+		return 0, fmt.Errorf("param (%s) doesn't exist", name)
+	}
+	return strconv.Atoi(value)
+}
+
+// Int64 is same as 'Int' method, but the result is an int64.
+func (xps XParams) Int64(name string) (int64, error) {
+	value, ok := xps.lookup(name)
+	if !ok {
+		return 0, fmt.Errorf("param (%s) doesn't exist", name)
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// Uint64 is same as 'Int' method, but the result is an uint64.
+func (xps XParams) Uint64(name string) (uint64, error) {
+	value, ok := xps.lookup(name)
+	if !ok {
+		return 0, fmt.Errorf("param (%s) doesn't exist", name)
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// Get the value of the first XParam which key matches the given name, and
+// report whether it exists.
+func (xps XParams) lookup(name string) (string, bool) {
+	for _, xp := range xps {
+		if xp.Key == name {
+			return xp.Value, true
+		}
+	}
+	return "", false
+}
+
 // When two 'XParams's are equal, they should meet the following conditions.
 // 1. XParams1.length = XParams2.length
 // 2. For each i between 0 and XParams1(2).length (exclude it): XParams1(i) = XParams2(i)
@@ -97,16 +140,30 @@ func DefaultMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, http.StatusText(405), 405)
 }
 
-var methods = []string{"GET", "POST", "HEAD", "PUT", "OPTIONS", "PATCH", "DELETE"}
+// The standard HTTP methods, 'Any' method registers a handle for all of them.
+var methods = []string{"GET", "POST", "HEAD", "PUT", "OPTIONS", "PATCH", "DELETE", "CONNECT", "TRACE"}
 
 // This function is used to check whether the http method is supported by XRouter.
+// Besides the standard methods, the WebDAV methods (such as PROPFIND and MKCOL)
+// and custom methods are also supported, so it only checks whether the method
+// is a valid token (RFC 7230). The method is case insensitive, because XRouter
+// converts it to uppercase when registering a route.
 func SupportMethod(method string) bool {
-	for _, m := range methods {
-		if strings.ToUpper(method) == m {
-			return true
+	if len(method) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(method); i++ {
+		if !isTokenChar(method[i]) {
+			return false
 		}
 	}
-	return false
+	return true
+}
+
+func isTokenChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
 }
 
 // XConfig is used to create a new XRouter.
@@ -158,7 +215,14 @@ type XConfig struct {
 // XRouter is the implementation of the 'http.Handler', which can be
 // dispatch requests to different handler functions via register routes.
 type XRouter struct {
-	trees map[string]*tree
+	// The routes are replaced as a whole when a transaction is committed (see
+	// 'Begin' method), the type of the value is *routeSet. They're read without
+	// locks, the modifications hold the read lock of 'rmtx', so they won't be
+	// applied to the replaced routes. The 'version' field is increased by each
+	// modification, it's used to detect the conflicts of transactions.
+	rs      atomic.Value
+	rmtx    sync.RWMutex
+	version uint64
 
 	// The following fields are same as the fields in XConfig
 	// except the first letter is lowercase. Because XRouter
@@ -171,6 +235,12 @@ type XRouter struct {
 	notFound               http.Handler
 	methodNotAllowed       http.Handler
 	panicHandler           func(http.ResponseWriter, *http.Request, interface{})
+
+	// The global middlewares (see 'Use' method), the type of the value is
+	// []Middleware. The slice is replaced entirely (copy-on-write) when it's
+	// changed, 'mwmtx' is only used to serialize modifications.
+	middlewares atomic.Value
+	mwmtx       sync.Mutex
 }
 
 // New returns a new initialized XRouter.
@@ -180,7 +250,6 @@ func New(xcfg *XConfig) *XRouter {
 	}
 
 	xr := &XRouter{
-		trees: make(map[string]*tree),
 		redirectTrailingSlash:  xcfg.RedirectTrailingSlash,
 		redirectFixedPath:      xcfg.RedirectFixedPath,
 		handleOptions:          xcfg.HandleOptions,
@@ -198,10 +267,8 @@ func New(xcfg *XConfig) *XRouter {
 		xr.methodNotAllowed = http.HandlerFunc(DefaultMethodNotAllowed)
 	}
 
-	for _, method := range methods {
-		xr.trees[method] = &tree{&sync.RWMutex{}, &node{}}
-	}
-
+	xr.rs.Store(newRouteSet())
+	xr.middlewares.Store([]Middleware(nil))
 	return xr
 }
 
@@ -230,6 +297,16 @@ func (xr *XRouter) OPTIONS(path string, handle XHandle) error {
 	return xr.Handle("OPTIONS", path, handle)
 }
 
+// CONNECT is a shortcut for Handle("CONNECT", path, handle)
+func (xr *XRouter) CONNECT(path string, handle XHandle) error {
+	return xr.Handle("CONNECT", path, handle)
+}
+
+// TRACE is a shortcut for Handle("TRACE", path, handle)
+func (xr *XRouter) TRACE(path string, handle XHandle) error {
+	return xr.Handle("TRACE", path, handle)
+}
+
 // PATCH is a shortcut for Handle("PATCH", path, handle)
 func (xr *XRouter) PATCH(path string, handle XHandle) error {
 	return xr.Handle("PATCH", path, handle)
@@ -257,6 +334,20 @@ func (xr *XRouter) DELETE(path string, handle XHandle) error {
 //   /blog/go/                           no match
 //   /blog/go/request-routers/comments   no match
 //
+// Named parameters can have a constraint, the value must satisfy it. The
+// builtin constraints are 'int', 'uint', 'alpha' and 'uuid', the others are
+// regular expressions (which can't contain '/'). The parameters of the same
+// segment with different constraints can coexist, the constrained ones take
+// precedence over the unconstrained one. A request which doesn't satisfy the
+// constraints falls through to the NotFound handler or the redirection:
+//  Path: /user/:id<int>
+//  Path: /user/:name<[a-z]+>
+//
+//  Requests:
+//   /user/123                           match: id="123"
+//   /user/blinklv                       match: name="blinklv"
+//   /user/Blinklv                       no match
+//
 // Catch-all parameters match anything until the path end, including the
 // directory index (the '/' before the catch-all). Since they match anything
 // until the end, catch-all parameters must always be the final path element.
@@ -279,16 +370,53 @@ func (xr *XRouter) DELETE(path string, handle XHandle) error {
 //  thirdValue  := xps[2].Value   // the value of the 3rd parameter
 //
 func (xr *XRouter) Handle(method, path string, handle XHandle) error {
+	return xr.handle(Scope{}, method, path, handle)
+}
+
+func (xr *XRouter) handle(s Scope, method, path string, handle XHandle) error {
+	return xr.modify(func(rs *routeSet) error { return rs.handle(s, method, path, handle) })
+}
+
+// Register a new request handle in the table of the scope.
+func (rs *routeSet) handle(s Scope, method, path string, handle XHandle) error {
 	if len(path) == 0 || path[0] != '/' {
 		return fmt.Errorf("path ('%s') must begin with '/'", path)
 	}
 
-	t := xr.trees[strings.ToUpper(method)]
-	if t == nil {
-		return fmt.Errorf("http method (%s) is unsupported", method)
+	if !SupportMethod(method) {
+		return fmt.Errorf("http method (%s) is invalid", method)
+	}
+
+	tb, err := rs.table(s, true)
+	if err != nil {
+		return err
 	}
+	return tb.getTree(strings.ToUpper(method), true).add(path, handle)
+}
+
+// Any registers a new request handle with the given path for all standard
+// methods (GET, POST, HEAD, PUT, OPTIONS, PATCH, DELETE, CONNECT and TRACE).
+// If the path has been registered for one of them, none of them will be
+// registered.
+func (xr *XRouter) Any(path string, handle XHandle) error {
+	return xr.any(Scope{}, path, handle)
+}
+
+func (xr *XRouter) any(s Scope, path string, handle XHandle) error {
+	return xr.modify(func(rs *routeSet) error { return rs.any(s, path, handle) })
+}
 
-	return t.add(path, handle)
+func (rs *routeSet) any(s Scope, path string, handle XHandle) error {
+	for i, method := range methods {
+		if err := rs.handle(s, method, path, handle); err != nil {
+			// Roll back the methods registered by this call.
+			for _, m := range methods[:i] {
+				rs.remove(s, m, path)
+			}
+			return err
+		}
+	}
+	return nil
 }
 
 // Remove unregister a existing request handle with given path and method.
@@ -297,12 +425,175 @@ func (xr *XRouter) Handle(method, path string, handle XHandle) error {
 // been registered, we can't use the argument '/foo/:xxx/who/are/*ooo' to
 // remove it.
 func (xr *XRouter) Remove(method, path string) error {
-	t := xr.trees[strings.ToUpper(method)]
+	return xr.remove(Scope{}, method, path)
+}
+
+func (xr *XRouter) remove(s Scope, method, path string) error {
+	return xr.modify(func(rs *routeSet) error { return rs.remove(s, method, path) })
+}
+
+// Unregister an existing request handle in the table of the scope.
+func (rs *routeSet) remove(s Scope, method, path string) error {
+	var (
+		t  *tree
+		tb *table
+	)
+
+	method = strings.ToUpper(method)
+	if tb, _ = rs.table(s, false); tb != nil {
+		t = tb.getTree(method, false)
+	}
+
 	if t == nil {
-		return fmt.Errorf("http method (%s) is unsupported", method)
+		return fmt.Errorf("path (%s) hasn't been registered", path)
+	}
+
+	if err := t.remove(path); err != nil {
+		return err
+	}
+	rs.removeName(s, method, path)
+	return nil
+}
+
+// Methods returns the methods which have at least one default route (not
+// including the scoped routes) in ascending order.
+func (xr *XRouter) Methods() []string {
+	return xr.current().tbl.methods()
+}
+
+// Get the current routes of the XRouter.
+func (xr *XRouter) current() *routeSet {
+	return xr.rs.Load().(*routeSet)
+}
+
+// Apply a modification to the current routes, the version is increased if
+// it succeeds.
+func (xr *XRouter) modify(fn func(*routeSet) error) error {
+	xr.rmtx.RLock()
+	defer xr.rmtx.RUnlock()
+
+	if err := fn(xr.current()); err != nil {
+		return err
+	}
+	atomic.AddUint64(&xr.version, 1)
+	return nil
+}
+
+// routeSet is all routes of an XRouter, including the default routes, the
+// scoped routes and the names of routes.
+type routeSet struct {
+	// The default routes, which match any request.
+	tbl *table
+
+	// The scoped routes (see 'Scope' method), the type of the value is
+	// []*scope. They're kept in the order of creation, which is also the
+	// order of matching. The slice is replaced entirely (copy-on-write) when
+	// a scope is created, 'smtx' is only used to serialize modifications.
+	scopes atomic.Value
+	smtx   sync.Mutex
+
+	// The named routes, see 'HandleNamed' method.
+	nmtx  sync.RWMutex
+	names map[string]*namedRoute
+}
+
+func newRouteSet() *routeSet {
+	rs := &routeSet{tbl: newTable(), names: make(map[string]*namedRoute)}
+	rs.scopes.Store([]*scope(nil))
+	return rs
+}
+
+// Get the scoped routes, the slice mustn't be modified.
+func (rs *routeSet) loadScopes() []*scope {
+	return rs.scopes.Load().([]*scope)
+}
+
+// table is a set of routes, it contains the trees of methods. The trees are
+// created on demand, the type of 'trees' is map[string]*tree. The map is
+// replaced entirely (copy-on-write) when a tree is created, so reading it
+// doesn't need a lock, 'mtx' is only used to serialize modifications. Each
+// tree is also copy-on-write.
+type table struct {
+	trees atomic.Value
+	mtx   sync.Mutex
+}
+
+func newTable() *table {
+	tb := &table{}
+	tb.trees.Store(make(map[string]*tree))
+	return tb
+}
+
+// Get the trees of methods, the map mustn't be modified.
+func (tb *table) load() map[string]*tree {
+	return tb.trees.Load().(map[string]*tree)
+}
+
+// Get the tree of a method, if it doesn't exist and 'create' is true, a new
+// one will be created.
+func (tb *table) getTree(method string, create bool) *tree {
+	t := tb.load()[method]
+	if t != nil || !create {
+		return t
+	}
+
+	tb.mtx.Lock()
+	defer tb.mtx.Unlock()
+
+	old := tb.load()
+	if t = old[method]; t == nil {
+		t = newTree()
+		trees := make(map[string]*tree, len(old)+1)
+		for m, ot := range old {
+			trees[m] = ot
+		}
+		trees[method] = t
+		tb.trees.Store(trees)
+	}
+	return t
+}
+
+// Check whether the path has a route (or can be redirected to a route by the
+// trailing slash) in any method.
+func (tb *table) routable(path string, tsr bool) bool {
+	for _, t := range tb.load() {
+		if handle, _, redirect := t.get(path, tsr); handle != nil || redirect > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the methods which have at least one route in ascending order.
+func (tb *table) methods() []string {
+	var result []string
+	for method, t := range tb.load() {
+		if !t.isempty() {
+			result = append(result, method)
+		}
 	}
+	sort.Strings(result)
+	return result
+}
+
+// Use installs global middlewares, which wrap the handles of all routes
+// (including the ones registered before calling it). The middlewares are
+// applied in order, the first one is the outermost one, and they're outside
+// the middlewares of groups and routes. The global middlewares are only
+// applied to the matched routes, the NotFound and MethodNotAllowed handlers
+// aren't wrapped.
+func (xr *XRouter) Use(middlewares ...Middleware) {
+	xr.mwmtx.Lock()
+	// Copy on write, so the slice read by 'wrap' method won't be modified.
+	old := xr.middlewares.Load().([]Middleware)
+	mws := make([]Middleware, 0, len(old)+len(middlewares))
+	xr.middlewares.Store(append(append(mws, old...), middlewares...))
+	xr.mwmtx.Unlock()
+}
 
-	return t.remove(path)
+// Wrap the handle of a matched route by the global middlewares.
+func (xr *XRouter) wrap(handle XHandle) XHandle {
+	return Wrap(handle, xr.middlewares.Load().([]Middleware)...)
 }
 
 // ServeHTTP is the implementation of the http.Handler interface.
@@ -313,13 +604,20 @@ func (xr *XRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	path, hasFixed := r.URL.Path, false
 
-	if t := xr.trees[r.Method]; t != nil {
+	// Select the routing table before searching the handle, the values of
+	// the wildcards in the host pattern precede the ones in the path.
+	tb, hxps := xr.current().match(r, path, xr.redirectTrailingSlash)
+
+	if t := tb.getTree(r.Method, false); t != nil {
 
 	fixed:
 		// If the results of the t.isempty function equals to true,
 		// the t.get function will also return the nil handle.
 		if handle, xps, tsr := t.get(path, xr.redirectTrailingSlash); handle != nil && !hasFixed {
-			handle(w, r, xps)
+			if len(hxps) > 0 {
+				xps = append(hxps, xps...)
+			}
+			xr.wrap(handle)(w, r, xps)
 			return
 		} else if r.Method != "CONNECT" && path != "/" {
 			code := 301 // Permanent redirect, request with GET method
@@ -351,7 +649,7 @@ func (xr *XRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "OPTIONS" {
 		if xr.handleOptions {
 			// Handle OPTIONS requests.
-			if allow := xr.allowed(r.URL.Path, r.Method); len(allow) > 0 {
+			if allow := xr.allowed(tb, r.URL.Path, r.Method); len(allow) > 0 {
 				w.Header().Set("Allow", allow)
 				return
 			}
@@ -359,7 +657,7 @@ func (xr *XRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// Handle 405.
 		if xr.handleMethodNotAllowed {
-			if allow := xr.allowed(r.URL.Path, r.Method); len(allow) > 0 {
+			if allow := xr.allowed(tb, r.URL.Path, r.Method); len(allow) > 0 {
 				w.Header().Set("Allow", allow)
 				xr.methodNotAllowed.ServeHTTP(w, r)
 				return
@@ -371,11 +669,14 @@ func (xr *XRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	xr.notFound.ServeHTTP(w, r)
 }
 
-func (xr *XRouter) allowed(path, reqMethod string) (allow string) {
-	var optionsAllowed bool
+func (xr *XRouter) allowed(tb *table, path, reqMethod string) (allow string) {
+	var (
+		optionsAllowed bool
+		trees          = tb.load()
+	)
 
 	if path == "*" && reqMethod == "OPTIONS" {
-		for method, t := range xr.trees {
+		for method, t := range trees {
 			if method == "OPTIONS" || t.isempty() {
 				continue
 			}
@@ -388,7 +689,7 @@ func (xr *XRouter) allowed(path, reqMethod string) (allow string) {
 			}
 		}
 	} else {
-		for method, t := range xr.trees {
+		for method, t := range trees {
 			if method == reqMethod || t.isempty() {
 				continue
 			}
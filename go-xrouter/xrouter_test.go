@@ -3,7 +3,7 @@
 // Author: blinklv <blinklv@icloud.com>
 // Create Time: 2017-06-26
 // Maintainer: blinklv <blinklv@icloud.com>
-// Last Change: 2017-07-25
+// Last Change: 2026-10-17
 package xrouter
 
 import (
@@ -71,9 +71,13 @@ func TestSupportMethod(t *testing.T) {
 		{"options", true},
 		{"PATCh", true},
 		{"DELETE", true},
-		{"CONNECT", false},
-		{"foo", false},
-		{"BAR", false},
+		{"CONNECT", true},
+		{"PROPFIND", true},
+		{"mkcol", true},
+		{"X-PURGE", true},
+		{"", false},
+		{"GET /", false},
+		{"B@R", false},
 	}
 
 	for _, pair := range methodPair {
@@ -93,7 +97,8 @@ func TestHandle(t *testing.T) {
 		{[]string{"GET", "POST"}, "hello/:world", false}, // path doesn't begin with '/'
 		{[]string{"GET", "POST"}, "", false},             // path doesn't begin with '/'
 		{[]string{"PUT", "OPTIONS", "DELETE"}, "/hello/:world", true},
-		{[]string{"CONNECT"}, "/foo/bar", false}, // method is unsupported
+		{[]string{"PROPFIND", "MKCOL"}, "/hello/:world", true},
+		{[]string{"B@R"}, "/foo/bar", false}, // method is invalid
 	}
 
 	xr := New(&XConfig{})
@@ -108,6 +113,67 @@ func TestHandle(t *testing.T) {
 	}
 }
 
+func TestCustomMethod(t *testing.T) {
+	xr := New(&XConfig{HandleMethodNotAllowed: true, HandleOptions: true})
+	xassert.Equal(t, len(xr.Methods()), 0)
+
+	handle := func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		w.Write([]byte(r.Method + ":" + xps.String()))
+	}
+	xassert.IsNil(t, xr.Handle("propfind", "/dav/*path", handle))
+	xassert.IsNil(t, xr.Handle("MKCOL", "/dav/*path", handle))
+	xassert.IsNil(t, xr.Handle("X-PURGE", "/cache/:key", handle))
+	xassert.IsNil(t, xr.CONNECT("/tunnel", handle))
+	xassert.IsNil(t, xr.GET("/dav/*path", handle))
+	xassert.Equal(t, xr.Methods(), []string{"CONNECT", "GET", "MKCOL", "PROPFIND", "X-PURGE"})
+
+	w := serve(xr, "PROPFIND", "/dav/a/b")
+	xassert.Equal(t, w.Code, 200)
+	xassert.Equal(t, w.Body.String(), "PROPFIND:path=a/b")
+	xassert.Equal(t, serve(xr, "X-PURGE", "/cache/foo").Body.String(), "X-PURGE:key=foo")
+	xassert.Equal(t, serve(xr, "CONNECT", "/tunnel").Body.String(), "CONNECT:")
+
+	// The dynamic methods are reported by the 405 and OPTIONS responses.
+	w = serve(xr, "PUT", "/dav/a")
+	xassert.Equal(t, w.Code, 405)
+	allow := strings.Split(w.Header().Get("Allow"), ", ")
+	sort.Strings(allow)
+	xassert.Equal(t, allow, []string{"GET", "MKCOL", "OPTIONS", "PROPFIND"})
+
+	w = serve(xr, "OPTIONS", "*")
+	allow = strings.Split(w.Header().Get("Allow"), ", ")
+	sort.Strings(allow)
+	xassert.Equal(t, allow, []string{"CONNECT", "GET", "MKCOL", "OPTIONS", "PROPFIND", "X-PURGE"})
+
+	xassert.IsNil(t, xr.Remove("X-PURGE", "/cache/:key"))
+	xassert.NotNil(t, xr.Remove("X-PURGE", "/cache/:key"))
+	xassert.NotNil(t, xr.Remove("UNKNOWN", "/cache/:key"))
+	xassert.Equal(t, xr.Methods(), []string{"CONNECT", "GET", "MKCOL", "PROPFIND"})
+}
+
+func TestAny(t *testing.T) {
+	xr := New(&XConfig{})
+	handle := func(w http.ResponseWriter, r *http.Request, xps XParams) {
+		w.Write([]byte(r.Method))
+	}
+
+	xassert.IsNil(t, xr.Any("/any", handle))
+	for _, method := range methods {
+		w := serve(xr, method, "/any")
+		xassert.Equal(t, w.Code, 200)
+		xassert.Equal(t, w.Body.String(), method)
+	}
+
+	// Nothing is registered if the path conflicts with an existing route.
+	xassert.IsNil(t, xr.PUT("/foo", handle))
+	xassert.NotNil(t, xr.Any("/foo", handle))
+	xassert.Equal(t, serve(xr, "GET", "/foo").Code, 404)
+	xassert.Equal(t, serve(xr, "PUT", "/foo").Code, 200)
+
+	xassert.IsNil(t, xr.Group("/api", generateMiddleware("a")).Any("/bar", handle))
+	xassert.Equal(t, serve(xr, "DELETE", "/api/bar").Body.String(), "a>DELETE")
+}
+
 func TestPanic(t *testing.T) {
 	xr := New(&XConfig{
 		PanicHandler: func(w http.ResponseWriter, r *http.Request, x interface{}) {